@@ -0,0 +1,108 @@
+package newbee
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Authenticator 验证玩家通过 Room 的 JoinWithToken 方法加入房间时提供的凭证，通过 WithAuthenticator 设置
+// 没有设置的时候，Room 会将凭证和房间的 token（WithToken）进行比较
+type Authenticator interface {
+	// Authenticate 验证凭证，now 为 Room 的 Clock 的当前时间，验证失败的时候返回 ErrBadToken 或者 ErrTokenExpired
+	// 本方法在调用 JoinWithToken 的协程中执行，需要保证并发安全
+	Authenticate(room Room, player Player, token string, now time.Time) error
+}
+
+// roomTokenAuthenticator 将凭证和房间的 token 进行比较，房间没有设置 token（WithToken）的时候所有凭证都验证失败
+type roomTokenAuthenticator struct{}
+
+func (roomTokenAuthenticator) Authenticate(room Room, player Player, token string, now time.Time) error {
+	var roomToken = room.GetToken()
+	if roomToken == "" || subtle.ConstantTimeCompare([]byte(roomToken), []byte(token)) != 1 {
+		return ErrBadToken
+	}
+	return nil
+}
+
+// TicketAuthenticator 使用 HMAC-SHA256 签名的入场券进行验证，入场券绑定了房间 id、玩家 id 和过期时间
+// 入场券通常由匹配服务通过 Issue 方法签发，玩家连接游戏服务器之后通过 Room 的 JoinWithToken 方法加入房间
+type TicketAuthenticator struct {
+	secret []byte
+}
+
+// NewTicketAuthenticator 创建 TicketAuthenticator，签发和验证入场券需要使用相同的 secret
+func NewTicketAuthenticator(secret []byte) *TicketAuthenticator {
+	var a = &TicketAuthenticator{}
+	a.secret = append([]byte(nil), secret...)
+	return a
+}
+
+// Issue 签发入场券，入场券只能用于 playerId 对应的玩家加入 roomId 对应的房间，并且在 expiresAt 之后失效
+func (a *TicketAuthenticator) Issue(roomId, playerId int64, expiresAt time.Time) string {
+	var payload = strconv.FormatInt(roomId, 10) + "." + strconv.FormatInt(playerId, 10) + "." + strconv.FormatInt(expiresAt.Unix(), 10)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(a.sign(payload))
+}
+
+func (a *TicketAuthenticator) Authenticate(room Room, player Player, token string, now time.Time) error {
+	var pos = strings.LastIndexByte(token, '.')
+	if pos < 0 {
+		return ErrBadToken
+	}
+
+	var payload = token[:pos]
+	var sig, err = base64.RawURLEncoding.DecodeString(token[pos+1:])
+	if err != nil || !hmac.Equal(sig, a.sign(payload)) {
+		return ErrBadToken
+	}
+
+	var fields = strings.Split(payload, ".")
+	if len(fields) != 3 {
+		return ErrBadToken
+	}
+
+	roomId, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil || roomId != room.GetId() {
+		return ErrBadToken
+	}
+
+	playerId, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || playerId != player.GetId() {
+		return ErrBadToken
+	}
+
+	expiresAt, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return ErrBadToken
+	}
+	if now.Unix() >= expiresAt {
+		return ErrTokenExpired
+	}
+	return nil
+}
+
+func (a *TicketAuthenticator) sign(payload string) []byte {
+	var h = hmac.New(sha256.New, a.secret)
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
+
+func (r *room) JoinWithToken(player Player, token string) error {
+	if player == nil {
+		return ErrNilPlayer
+	}
+
+	var authenticator = r.authenticator
+	if authenticator == nil {
+		authenticator = roomTokenAuthenticator{}
+	}
+
+	if err := authenticator.Authenticate(r, player, token, r.clock.Now()); err != nil {
+		return err
+	}
+	return r.AddPlayer(player)
+}
@@ -0,0 +1,78 @@
+package newbee_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/smartwalle/newbee"
+	"github.com/smartwalle/newbee/newbeetest"
+)
+
+func TestJoinWithRoomToken(t *testing.T) {
+	var game = newbeetest.NewGame(1)
+	var room = newbeetest.RunRoom(t, game, newbee.WithSync(), newbee.WithToken("secret"))
+
+	var sess = newbeetest.NewSession()
+	if err := room.JoinWithToken(newbee.NewPlayer(1, sess), "guess"); !errors.Is(err, newbee.ErrBadToken) {
+		t.Fatalf("got %v, want %v", err, newbee.ErrBadToken)
+	}
+	if sess.Inject(newPacket("hello")) {
+		t.Fatal("rejected session was bound to the room")
+	}
+
+	if err := room.JoinWithToken(newbee.NewPlayer(1, newbeetest.NewSession()), "secret"); err != nil {
+		t.Fatal(err)
+	}
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom")
+}
+
+func TestJoinWithoutRoomToken(t *testing.T) {
+	var game = newbeetest.NewGame(1)
+	var room = newbeetest.RunRoom(t, game, newbee.WithSync())
+
+	// 房间没有设置 token 的时候，空的凭证也不能通过验证
+	if err := room.JoinWithToken(newbee.NewPlayer(1, newbeetest.NewSession()), ""); !errors.Is(err, newbee.ErrBadToken) {
+		t.Fatalf("got %v, want %v", err, newbee.ErrBadToken)
+	}
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom")
+}
+
+func TestTicketAuthenticator(t *testing.T) {
+	var clock = newbee.NewFakeClock(time.Unix(1000, 0))
+	var authenticator = newbee.NewTicketAuthenticator([]byte("secret"))
+	var game = newbeetest.NewGame(1)
+	game.Interval = 0
+	var room = newbeetest.RunRoom(t, game, newbee.WithSync(), newbee.WithClock(clock), newbee.WithAuthenticator(authenticator))
+
+	var ticket = authenticator.Issue(room.GetId(), 1, clock.Now().Add(time.Minute))
+
+	var tests = []struct {
+		name     string
+		playerId int64
+		ticket   string
+		err      error
+	}{
+		{name: "other player", playerId: 2, ticket: ticket, err: newbee.ErrBadToken},
+		{name: "other room", playerId: 1, ticket: authenticator.Issue(room.GetId()+1, 1, clock.Now().Add(time.Minute)), err: newbee.ErrBadToken},
+		{name: "other secret", playerId: 1, ticket: newbee.NewTicketAuthenticator([]byte("guess")).Issue(room.GetId(), 1, clock.Now().Add(time.Minute)), err: newbee.ErrBadToken},
+		{name: "tampered", playerId: 1, ticket: ticket + "x", err: newbee.ErrBadToken},
+		{name: "malformed", playerId: 1, ticket: "ticket", err: newbee.ErrBadToken},
+		{name: "expired", playerId: 1, ticket: authenticator.Issue(room.GetId(), 1, clock.Now()), err: newbee.ErrTokenExpired},
+	}
+	for _, test := range tests {
+		if err := room.JoinWithToken(newbee.NewPlayer(test.playerId, newbeetest.NewSession()), test.ticket); !errors.Is(err, test.err) {
+			t.Fatalf("%s: got %v, want %v", test.name, err, test.err)
+		}
+	}
+
+	if err := room.JoinWithToken(newbee.NewPlayer(1, newbeetest.NewSession()), ticket); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(time.Minute)
+	if err := room.JoinWithToken(newbee.NewPlayer(1, newbeetest.NewSession()), ticket); !errors.Is(err, newbee.ErrTokenExpired) {
+		t.Fatalf("got %v, want %v", err, newbee.ErrTokenExpired)
+	}
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom")
+}
@@ -0,0 +1,156 @@
+package newbee
+
+import (
+	"github.com/smartwalle/net4go"
+	"sync"
+)
+
+// Bot 机器人玩家，机器人不需要网络连接，可以和普通玩家一样通过 Room 的 AddPlayer 方法加入房间
+type Bot interface {
+	Player
+
+	// Input 模拟机器人发送消息，消息会和玩家通过网络发送的消息一样放入 Room 的队列，交由 Game 的 OnMessage 方法处理
+	// 返回消息是否被放入队列，机器人没有加入房间、已经关闭、消息超出频率限制（WithRateLimit）或者队列已满的时候返回 false
+	// 本方法不会阻塞，可以在 Game 的回调方法中调用，即使设置了 WithQueueCapacity 和 QueuePolicyBlock，队列已满的时候消息也会被直接丢弃
+	Input(packet net4go.Packet) bool
+}
+
+type bot struct {
+	*player
+	sess *botSession
+}
+
+// NewBot 创建机器人，Room 向机器人发送的消息（SendPacket、BroadcastPacket 等）会交由 handler 处理
+// handler 在发送消息的协程中执行（通常是 Room 的消息处理协程），SendPacket 和 BroadcastPacket 等方法发送消息的时候不会持有 Room 的锁，
+// 所以 handler 中可以调用 Bot 的 Input 方法以及 Room 的其它方法（在 RangePlayer 的回调中发送的消息除外）
+func NewBot(id int64, handler func(packet net4go.Packet)) Bot {
+	var b = &bot{}
+	b.sess = newBotSession(handler)
+	b.player = &player{id: id, sess: b.sess}
+	return b
+}
+
+// IsBot 玩家是否为机器人，实现了 Bot 接口的玩家（例如 NewBot 创建的玩家）即为机器人
+func IsBot(player Player) bool {
+	var _, ok = player.(Bot)
+	return ok
+}
+
+func (b *bot) Input(packet net4go.Packet) bool {
+	return b.sess.input(packet)
+}
+
+// botInputHandler 机器人消息的处理器（Room 和观察者的消息处理器），处理消息并返回消息是否被放入队列
+type botInputHandler interface {
+	input(sess net4go.Session, p net4go.Packet) bool
+}
+
+// botSession 没有网络连接的 net4go.Session，写入的消息会交由 onPacket 处理
+type botSession struct {
+	handler  net4go.Handler
+	onPacket func(packet net4go.Packet)
+	data     map[string]interface{}
+	id       int64
+	mu       sync.Mutex
+	closed   bool
+}
+
+func newBotSession(onPacket func(packet net4go.Packet)) *botSession {
+	return &botSession{onPacket: onPacket}
+}
+
+func (s *botSession) Conn() interface{} {
+	return nil
+}
+
+func (s *botSession) SetId(id int64) {
+	s.mu.Lock()
+	s.id = id
+	s.mu.Unlock()
+}
+
+func (s *botSession) GetId() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.id
+}
+
+func (s *botSession) UpdateHandler(handler net4go.Handler) {
+	s.mu.Lock()
+	s.handler = handler
+	s.mu.Unlock()
+}
+
+func (s *botSession) Set(key string, value interface{}) {
+	s.mu.Lock()
+	if s.data == nil {
+		s.data = make(map[string]interface{})
+	}
+	s.data[key] = value
+	s.mu.Unlock()
+}
+
+func (s *botSession) Get(key string) interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data[key]
+}
+
+func (s *botSession) Del(key string) {
+	s.mu.Lock()
+	delete(s.data, key)
+	s.mu.Unlock()
+}
+
+func (s *botSession) Closed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+func (s *botSession) AsyncWritePacket(p net4go.Packet) error {
+	return s.WritePacket(p)
+}
+
+func (s *botSession) WritePacket(p net4go.Packet) error {
+	if s.Closed() {
+		return net4go.ErrSessionClosed
+	}
+	if s.onPacket != nil {
+		s.onPacket(p)
+	}
+	return nil
+}
+
+func (s *botSession) input(p net4go.Packet) bool {
+	s.mu.Lock()
+	var handler = s.handler
+	var closed = s.closed
+	s.mu.Unlock()
+
+	if closed || handler == nil {
+		return false
+	}
+	if h, ok := handler.(botInputHandler); ok {
+		return h.input(s, p)
+	}
+	handler.OnMessage(s, p)
+	return true
+}
+
+func (s *botSession) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	var handler = s.handler
+	s.handler = nil
+	s.closed = true
+	s.mu.Unlock()
+
+	if handler != nil {
+		handler.OnClose(s, nil)
+	}
+	return nil
+}
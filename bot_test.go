@@ -0,0 +1,139 @@
+package newbee_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/smartwalle/net4go"
+	"github.com/smartwalle/newbee"
+	"github.com/smartwalle/newbee/newbeetest"
+)
+
+func TestBot(t *testing.T) {
+	for name, mode := range newbeetest.Modes() {
+		if name == "lockstep" {
+			continue
+		}
+
+		t.Run(name, func(t *testing.T) {
+			var game = newbeetest.NewGame(1)
+			var room = newbeetest.RunRoom(t, game, mode)
+
+			var received int32
+			var bot = newbee.NewBot(1, func(packet net4go.Packet) {
+				atomic.AddInt32(&received, 1)
+			})
+			if err := room.AddPlayer(bot); err != nil {
+				t.Fatal(err)
+			}
+			if !newbee.IsBot(room.GetPlayer(1)) {
+				t.Fatal("IsBot returned false for a bot")
+			}
+			if newbee.IsBot(newbee.NewPlayer(2, newbeetest.NewSession())) {
+				t.Fatal("IsBot returned true for a player")
+			}
+
+			if !bot.Input(newPacket("hello")) {
+				t.Fatal("Input returned false for a bot in the room")
+			}
+			newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnMessage")
+
+			room.BroadcastPacket(newPacket("world"))
+			if atomic.LoadInt32(&received) != 1 {
+				t.Fatalf("bot received %d packets, want 1", received)
+			}
+
+			bot.Close()
+			newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnMessage", "OnLeaveRoom")
+			if bot.Input(newPacket("closed")) {
+				t.Fatal("Input returned true for a closed bot")
+			}
+		})
+	}
+}
+
+// broadcastGame 在处理自定义消息的时候向所有玩家广播该消息
+type broadcastGame struct {
+	*newbeetest.Game
+}
+
+func (g broadcastGame) OnDequeue(message interface{}) {
+	g.Game.OnDequeue(message)
+	g.Room().BroadcastPacket(message.(net4go.Packet))
+}
+
+// TestBotInputInHandler 机器人在 handler 中调用 Input 不能死锁
+func TestBotInputInHandler(t *testing.T) {
+	for name, mode := range newbeetest.Modes() {
+		if name == "lockstep" {
+			continue
+		}
+
+		t.Run(name, func(t *testing.T) {
+			var game = broadcastGame{newbeetest.NewGame(1)}
+			var room = newbeetest.RunRoom(t, game, mode, newbee.WithPausePolicy(newbee.PausePolicyReject))
+
+			var bot newbee.Bot
+			bot = newbee.NewBot(1, func(packet net4go.Packet) {
+				bot.Input(packet)
+			})
+			if err := room.AddPlayer(bot); err != nil {
+				t.Fatal(err)
+			}
+
+			room.Enqueue(newPacket("ping"))
+			newbeetest.ExpectCalls(t, game.Game, "OnRunInRoom", "OnJoinRoom", "OnDequeue", "OnMessage")
+		})
+	}
+}
+
+func TestBotInputQueueFull(t *testing.T) {
+	var game = broadcastGame{newbeetest.NewGame(1)}
+	game.Interval = 0
+	var room = newbeetest.RunRoom(t, game, newbee.WithAsync(), newbee.WithQueueCapacity(1, newbee.QueuePolicyBlock))
+
+	var accepted []bool
+	var bot newbee.Bot
+	bot = newbee.NewBot(1, func(packet net4go.Packet) {
+		for i := 0; i < 5; i++ {
+			accepted = append(accepted, bot.Input(packet))
+		}
+	})
+	if err := room.AddPlayer(bot); err != nil {
+		t.Fatal(err)
+	}
+
+	// handler 在 Room 的消息处理协程中执行，队列已满的时候 Input 不能阻塞
+	room.Enqueue(newPacket("ping"))
+	newbeetest.ExpectCalls(t, game.Game, "OnRunInRoom", "OnJoinRoom", "OnDequeue", "OnMessage")
+
+	if n := room.GetDroppedCount(); n != 4 {
+		t.Fatalf("got %d dropped messages, want 4", n)
+	}
+
+	// 只有第一条消息被放入队列
+	if len(accepted) != 5 || !accepted[0] || accepted[1] || accepted[4] {
+		t.Fatalf("got %v, want [true false false false false]", accepted)
+	}
+}
+
+func TestBotInputRateLimited(t *testing.T) {
+	var clock = newbee.NewFakeClock(time.Now())
+	var game = newbeetest.NewGame(1)
+	game.Interval = 0
+	var room = newbeetest.RunRoom(t, game, newbee.WithAsync(), newbee.WithClock(clock), newbee.WithRateLimit(1, 1, newbee.RateLimitPolicyDrop))
+
+	var bot = newbee.NewBot(1, nil)
+	if err := room.AddPlayer(bot); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bot.Input(newPacket("a")) {
+		t.Fatal("Input returned false for an allowed message")
+	}
+	if bot.Input(newPacket("b")) {
+		t.Fatal("Input returned true for a rate limited message")
+	}
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnMessage")
+}
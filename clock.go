@@ -0,0 +1,49 @@
+package newbee
+
+import (
+	"time"
+)
+
+// Clock 时钟，Room 中所有和时间相关的操作（定时器、断线重连计时、频率限制等）都通过 Clock 完成
+// 默认使用系统时钟，测试的时候可以通过 WithClock 设置为 FakeClock，手动控制时间的流逝
+type Clock interface {
+	// Now 获取当前时间
+	Now() time.Time
+
+	// NewTimer 创建定时器，d 之后会向定时器的 C() 写入当前时间
+	NewTimer(d time.Duration) ClockTimer
+
+	// AfterFunc d 之后执行 fn
+	AfterFunc(d time.Duration, fn func()) ClockTimer
+}
+
+type ClockTimer interface {
+	C() <-chan time.Time
+
+	Stop() bool
+
+	Reset(d time.Duration) bool
+}
+
+type realClock struct {
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) ClockTimer {
+	return &realTimer{Timer: time.NewTimer(d)}
+}
+
+func (realClock) AfterFunc(d time.Duration, fn func()) ClockTimer {
+	return &realTimer{Timer: time.AfterFunc(d, fn)}
+}
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (t *realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
@@ -0,0 +1,141 @@
+package newbee
+
+import (
+	"sync"
+	"time"
+)
+
+// FakeClock 手动控制的时钟，时间只会在调用 Advance 方法的时候流逝，用于编写确定性的测试
+type FakeClock struct {
+	cond   *sync.Cond
+	now    time.Time
+	timers []*fakeTimer
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	var c = &FakeClock{}
+	c.cond = sync.NewCond(&sync.Mutex{})
+	c.now = now
+	return c
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.cond.L.Lock()
+	defer c.cond.L.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) NewTimer(d time.Duration) ClockTimer {
+	var t = &fakeTimer{clock: c, ch: make(chan time.Time, 1)}
+	c.add(t, d)
+	return t
+}
+
+func (c *FakeClock) AfterFunc(d time.Duration, fn func()) ClockTimer {
+	var t = &fakeTimer{clock: c, fn: fn}
+	c.add(t, d)
+	return t
+}
+
+// Advance 将时间向前推进 d，期间到期的定时器会按照到期时间的先后顺序依次触发
+// AfterFunc 创建的定时器会在调用 Advance 的协程中执行
+func (c *FakeClock) Advance(d time.Duration) {
+	c.cond.L.Lock()
+	var end = c.now.Add(d)
+
+	for {
+		var next *fakeTimer
+		for _, t := range c.timers {
+			if t.active && !t.when.After(end) && (next == nil || t.when.Before(next.when)) {
+				next = t
+			}
+		}
+		if next == nil {
+			break
+		}
+
+		c.now = next.when
+		c.remove(next)
+
+		var now = c.now
+		c.cond.L.Unlock()
+		next.fire(now)
+		c.cond.L.Lock()
+	}
+
+	c.now = end
+	c.cond.L.Unlock()
+}
+
+// BlockUntil 阻塞直到时钟上有 n 个未触发的定时器，用于等待 Room 在其它协程中创建定时器
+func (c *FakeClock) BlockUntil(n int) {
+	c.cond.L.Lock()
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+	c.cond.L.Unlock()
+}
+
+func (c *FakeClock) add(t *fakeTimer, d time.Duration) {
+	c.cond.L.Lock()
+	t.when = c.now.Add(d)
+	t.active = true
+	c.timers = append(c.timers, t)
+	c.cond.L.Unlock()
+	c.cond.Broadcast()
+}
+
+func (c *FakeClock) remove(t *fakeTimer) bool {
+	if !t.active {
+		return false
+	}
+	t.active = false
+	for i, item := range c.timers {
+		if item == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+type fakeTimer struct {
+	clock  *FakeClock
+	when   time.Time
+	ch     chan time.Time
+	fn     func()
+	active bool
+}
+
+func (t *fakeTimer) fire(now time.Time) {
+	if t.fn != nil {
+		t.fn()
+		return
+	}
+
+	// 和 time.Timer 保持一致，如果上一次写入的时间还没有被读取，则丢弃本次的时间
+	select {
+	case t.ch <- now:
+	default:
+	}
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.cond.L.Lock()
+	defer t.clock.cond.L.Unlock()
+	return t.clock.remove(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	var c = t.clock
+	c.cond.L.Lock()
+	var active = c.remove(t)
+	c.cond.L.Unlock()
+
+	c.add(t, d)
+	return active
+}
@@ -0,0 +1,65 @@
+package newbee_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/smartwalle/newbee"
+	"github.com/smartwalle/newbee/newbeetest"
+)
+
+func TestFakeClock(t *testing.T) {
+	var start = time.Unix(1000, 0)
+	var clock = newbee.NewFakeClock(start)
+
+	var fired []string
+	clock.AfterFunc(time.Second*2, func() { fired = append(fired, "2s") })
+	clock.AfterFunc(time.Second, func() { fired = append(fired, "1s") })
+	var stopped = clock.AfterFunc(time.Second, func() { fired = append(fired, "stopped") })
+	var timer = clock.NewTimer(time.Second * 3)
+
+	if !stopped.Stop() {
+		t.Fatal("Stop returned false for an active timer")
+	}
+
+	clock.Advance(time.Second * 2)
+	if len(fired) != 2 || fired[0] != "1s" || fired[1] != "2s" {
+		t.Fatalf("got %v, want [1s 2s]", fired)
+	}
+	if now := clock.Now(); !now.Equal(start.Add(time.Second * 2)) {
+		t.Fatalf("got %v, want %v", now, start.Add(time.Second*2))
+	}
+
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired early")
+	default:
+	}
+
+	clock.Advance(time.Second)
+	if now := <-timer.C(); !now.Equal(start.Add(time.Second * 3)) {
+		t.Fatalf("got %v, want %v", now, start.Add(time.Second*3))
+	}
+	if timer.Stop() {
+		t.Fatal("Stop returned true for a fired timer")
+	}
+}
+
+func TestFakeClockTick(t *testing.T) {
+	for name, mode := range newbeetest.Modes() {
+		t.Run(name, func(t *testing.T) {
+			var clock = newbee.NewFakeClock(time.Now())
+			var game = newbeetest.NewGame(1)
+			newbeetest.RunRoom(t, game, mode, newbee.WithClock(clock))
+
+			for i := 1; i <= 3; i++ {
+				clock.BlockUntil(1)
+				clock.Advance(game.Interval)
+				waitFor(t, "the next tick", func() bool { return game.Ticks() >= i })
+			}
+			if game.Ticks() != 3 {
+				t.Fatalf("got %d ticks, want 3", game.Ticks())
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/gorilla/websocket"
 	"github.com/smartwalle/net4go"
@@ -20,35 +21,24 @@ func main() {
 	var tcpp = &protocol.TCPProtocol{}
 	var wsp = &protocol.WSProtocol{}
 	var mu = &sync.Mutex{}
-	var waiter = &sync.WaitGroup{}
 
 	var roomCount = int64(1)
 
-	var rooms = make(map[int64]newbee.Room)
+	var manager = newbee.NewRoomManager(newbee.WithRoomDoneHandler(func(room newbee.Room, err error) {
+		if err != nil {
+			fmt.Println("游戏异常结束:", room.GetId())
+		} else {
+			fmt.Println("游戏正常结束:", room.GetId())
+		}
+	}))
 
 	for i := int64(0); i < roomCount; i++ {
-		var room = newbee.NewRoom(i, newbee.WithWaiter(waiter), newbee.WithFrame())
-		rooms[i] = room
-
-		var game = &Game{id: i}
-		go func() {
-			fmt.Println("开始游戏...")
-			var err = room.Run(game)
-			if err != nil {
-				fmt.Println("游戏异常结束:", room.GetId())
-			} else {
-				fmt.Println("游戏正常结束:", room.GetId())
-			}
-			mu.Lock()
-			delete(rooms, room.GetId())
-			mu.Unlock()
-
-		}()
+		fmt.Println("开始游戏...")
+		if _, err := manager.RunRoom(i, NewGame(i), newbee.WithFrame()); err != nil {
+			fmt.Println("启动游戏发生错误:", err)
+		}
 	}
 
-	// sleep 一会儿，让 Room 运行 Game
-	time.Sleep(time.Second * 1)
-
 	var playerId int64 = 0
 
 	// ws
@@ -71,7 +61,7 @@ func main() {
 			playerId = playerId + 1
 
 			var roomId = playerId % roomCount
-			var room = rooms[roomId]
+			var room = manager.Get(roomId)
 			if room != nil {
 				room.AddPlayer(newbee.NewPlayer(playerId, nSess))
 			}
@@ -102,7 +92,7 @@ func main() {
 			playerId = playerId + 1
 
 			var roomId = playerId % roomCount
-			var room = rooms[roomId]
+			var room = manager.Get(roomId)
 			if room != nil {
 				if err := room.AddPlayer(newbee.NewPlayer(playerId, nSess)); err != nil {
 					fmt.Println("加入房间发生错误", err)
@@ -134,7 +124,7 @@ func main() {
 	//		mu.Lock()
 	//
 	//		var roomId = playerId % roomCount
-	//		var room = rooms[roomId]
+	//		var room = manager.Get(roomId)
 	//		if room != nil {
 	//			room.AddPlayer(newbee.NewPlayer(playerId, nSess))
 	//		}
@@ -158,15 +148,10 @@ MainLoop:
 
 	fmt.Println("开始关闭游戏.")
 
-	mu.Lock()
-	for _, room := range rooms {
-		fmt.Println("关闭", room.GetId(), room.Close())
-	}
-	mu.Unlock()
-
 	fmt.Println("关闭中...")
-	waiter.Wait()
-	fmt.Println("结束.")
+	var ctx, cancel = context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	fmt.Println("结束.", manager.Shutdown(ctx))
 }
 
 //func generateTLSConfig() *tls.Config {
@@ -193,10 +178,23 @@ MainLoop:
 //}
 
 type Game struct {
-	id        int64
-	room      newbee.Room
-	state     newbee.GameState
-	tickCount int64
+	*newbee.Router[protocol.PacketType]
+	id    int64
+	room  newbee.Room
+	state newbee.GameState
+}
+
+func NewGame(id int64) *Game {
+	var g = &Game{id: id}
+	g.Router = newbee.NewRouter(func(message interface{}) (protocol.PacketType, bool) {
+		var p, ok = message.(*protocol.Packet)
+		if !ok || p == nil {
+			return 0, false
+		}
+		return p.Type, true
+	})
+	newbee.Handle(g.Router, protocol.Heartbeat, g.OnHeartbeat)
+	return g
 }
 
 func (this *Game) GetId() int64 {
@@ -212,29 +210,26 @@ func (this *Game) TickInterval() time.Duration {
 }
 
 func (this *Game) OnTick() {
-	this.tickCount++
+}
 
-	//if this.id == 1 && this.tickCount > 600 {
+// OnTickInfo 实现了 newbee.TickerWithInfo 接口，Room 会调用本方法代替 OnTick
+func (this *Game) OnTickInfo(info newbee.TickInfo) {
+	//if this.id == 1 && info.Frame > 600 {
 	//	var a = 0
 	//	fmt.Println(a / a)
 	//}
 
-	//fmt.Println(this.GetId(), "OnTick", time.Now(), this.tickCount)
+	//fmt.Println(this.GetId(), "OnTick", info.Now, info.Frame, info.Delta)
 }
 
-func (this *Game) OnMessage(player newbee.Player, message interface{}) {
-	if p := message.(*protocol.Packet); p != nil {
-		switch p.Type {
-		case protocol.Heartbeat:
-			p.Message = "来自服务器的消息"
-			player.AsyncSendPacket(p)
+func (this *Game) OnHeartbeat(player newbee.Player, p *protocol.Packet) {
+	p.Message = "来自服务器的消息"
+	player.AsyncSendPacket(p)
 
-			this.room.Enqueue(fmt.Sprintf("%s haha %d", time.Now(), player.GetId()))
+	this.room.Enqueue(fmt.Sprintf("%s haha %d", time.Now(), player.GetId()))
 
-			//player.Close()
-			//this.room.RemovePlayer(player.GetId())
-		}
-	}
+	//player.Close()
+	//this.room.RemovePlayer(player.GetId())
 }
 
 func (this *Game) OnDequeue(message interface{}) {
@@ -1,6 +1,7 @@
 package newbee
 
 import (
+	"github.com/smartwalle/net4go"
 	"time"
 )
 
@@ -17,7 +18,7 @@ type Game interface {
 	// GetId 获取游戏 id
 	GetId() int64
 
-	// GetState 游戏状态
+	// GetState 游戏状态，Room 会在调用 Game 的回调方法之后检查游戏状态的变化，参考 StateChangeHandler
 	GetState() GameState
 
 	// TickInterval 返回刷新时间间隔，Room 将按照该时间间隔调用 OnTick() 方法，返回小于等于 0 的时候，将禁用定时刷新
@@ -38,7 +39,7 @@ type Game interface {
 	// OnJoinRoom 玩家建立网络连接会调用此方法
 	OnJoinRoom(player Player)
 
-	// OnLeaveRoom 玩家断开网络连接会调用此方法
+	// OnLeaveRoom 玩家断开网络连接会调用此方法，err 为 *LeaveError，可以通过 GetLeaveReason 获取玩家离开的原因
 	OnLeaveRoom(player Player, err error)
 
 	// OnCloseRoom 房间关闭的时候会调用此方法
@@ -47,3 +48,85 @@ type Game interface {
 	// OnPanic 有未捕获异常的时候会调用此方法
 	OnPanic(room Room, err error)
 }
+
+// ReconnectHandler Game 可以选择实现本接口，用于接收玩家断线和重连的通知，需要配合 WithReconnectTimeout 使用
+type ReconnectHandler interface {
+	// OnPlayerDisconnected 玩家网络连接断开之后会调用此方法，此时玩家依然在房间中
+	OnPlayerDisconnected(player Player, err error)
+
+	// OnPlayerReconnected 玩家通过 Room 的 ReconnectPlayer 方法绑定新的连接之后会调用此方法
+	OnPlayerReconnected(player Player)
+}
+
+// ObserverHandler Game 可以选择实现本接口，用于处理观察者发送的消息，如果没有实现本接口，观察者发送的消息将被丢弃
+type ObserverHandler interface {
+	// OnObserverMessage 处理观察者消息
+	OnObserverMessage(observer Player, message interface{})
+}
+
+// FrameHandler 帧同步（WithLockstep）模式下，Game 需要实现本接口
+type FrameHandler interface {
+	// OnFrame 每一帧会调用此方法，frameId 从 1 开始单调递增，inputs 为本帧内收集到的玩家输入（key 为玩家 id）
+	// 返回值不为 nil 的时候，Room 会将其广播给所有玩家
+	OnFrame(frameId uint64, inputs map[int64][]interface{}) net4go.Packet
+}
+
+// RateLimitHandler Game 可以选择实现本接口，用于处理超出频率限制的玩家消息，需要配合 WithRateLimit 和 RateLimitPolicyNotify 使用
+type RateLimitHandler interface {
+	// OnRateLimited 玩家发送的消息超出频率限制的时候会调用此方法，message 为被丢弃的消息
+	OnRateLimited(player Player, message interface{})
+}
+
+// CallHandler Game 可以选择实现本接口，用于处理通过 Room 的 Call 方法发送的请求
+type CallHandler interface {
+	// OnCall 处理请求，返回值将作为 Room 的 Call 方法的返回值
+	OnCall(request interface{}) (interface{}, error)
+}
+
+// TickOverrunHandler Game 可以选择实现本接口，用于处理超时的 tick
+type TickOverrunHandler interface {
+	// OnTickOverrun 一次 tick 从预定的时间开始到执行完成超过了 TickInterval 的时候会调用此方法
+	OnTickOverrun(overrun TickOverrun)
+}
+
+// TickerWithInfo Game 可以选择实现本接口，实现之后 Room 会调用 OnTickInfo 代替 OnTick
+// TickInfo 中的时间来自 Room 的 Clock，使用 FakeClock 或者 Replay 的时候，OnTickInfo 收到的信息是确定的
+type TickerWithInfo interface {
+	// OnTickInfo 定时器，info 为本次 tick 的序号、和上一次 tick 的时间间隔以及开始的时间
+	OnTickInfo(info TickInfo)
+}
+
+// PauseHandler Game 可以选择实现本接口，用于接收房间暂停和恢复的通知
+type PauseHandler interface {
+	// OnPause 调用 Room 的 Pause 方法之后，Room 会在消息处理协程中调用此方法
+	OnPause()
+
+	// OnResume 调用 Room 的 Resume 方法之后，Room 会在消息处理协程中调用此方法，之后再处理暂停期间缓存的玩家消息
+	OnResume()
+}
+
+// StateChangeHandler Game 可以选择实现本接口，用于接收游戏状态变化的通知
+// Room 会在调用 Game 的回调方法之后检查 GetState 的返回值，合法的状态变化为：
+// GameStatePending -> GameStateGaming、GameStateStop
+// GameStateGaming -> GameStateOver、GameStateStop
+// GameStateOver -> GameStatePending、GameStateStop
+// Room 只能观察到回调方法结束时的状态，一次回调中最多只能进行一次状态变化
+// 其它的状态变化（例如 GameStatePending -> GameStateOver、离开 GameStateStop）不合法，会以 ErrBadGameState 交由 Game 的 OnPanic 方法处理，
+// 不会调用 OnStateChange，也不会关闭房间
+type StateChangeHandler interface {
+	// OnStateChange 游戏状态发生变化之后会调用此方法
+	OnStateChange(from, to GameState)
+}
+
+// JoinRequestHandler Game 可以选择实现本接口，用于在玩家加入房间之前进行检查
+type JoinRequestHandler interface {
+	// OnJoinRequest 玩家加入房间之前，Room 会在消息处理协程中调用此方法，此时玩家还没有添加到房间中
+	// 返回的错误信息不为空的时候，玩家不会加入房间，Room 的 AddPlayer 方法会返回该错误信息
+	OnJoinRequest(player Player) error
+}
+
+// IdleHandler Game 可以选择实现本接口，用于处理空闲超时的玩家，需要配合 WithIdleTimeout 和 IdleActionNotify 使用
+type IdleHandler interface {
+	// OnPlayerIdle 玩家超过 WithIdleTimeout 设置的时间没有发送消息的时候会调用此方法，idle 为玩家的空闲时间
+	OnPlayerIdle(player Player, idle time.Duration)
+}
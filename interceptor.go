@@ -0,0 +1,45 @@
+package newbee
+
+type EventType int
+
+const (
+	EventMessage   EventType = 1 // 玩家消息，对应 Game 的 OnMessage 方法，WithLockstep 模式下对应当前帧的输入
+	EventDequeue   EventType = 2 // 自定义消息，对应 Game 的 OnDequeue 方法
+	EventJoinRoom  EventType = 3 // 玩家加入房间，对应 Game 的 OnJoinRoom 方法
+	EventLeaveRoom EventType = 4 // 玩家离开房间，对应 Game 的 OnLeaveRoom 方法
+)
+
+// Event 交由 Game 处理的事件
+type Event struct {
+	Player  Player      // 玩家信息，EventDequeue 事件为 nil
+	Message interface{} // 消息内容，只在 EventMessage 和 EventDequeue 事件中有效
+	Error   error       // 玩家离开房间的原因，只在 EventLeaveRoom 事件中有效
+	Type    EventType
+}
+
+// Handler 事件处理函数，返回的错误只对 EventJoinRoom 事件有效，会作为 Room 的 AddPlayer 方法的返回值
+type Handler func(game Game, event Event) error
+
+// Interceptor 拦截器，拦截器可以修改事件之后再交由 next 处理，也可以不调用 next 直接返回
+// 对于 EventJoinRoom 事件，不调用 next 的时候玩家不会被添加到房间中，此时应该返回相应的错误
+type Interceptor func(next Handler) Handler
+
+// handle 拦截器链的最后一环，将事件交由 Game 处理
+func (r *room) handle(game Game, event Event) error {
+	switch event.Type {
+	case EventMessage:
+		// WithLockstep 模式下玩家消息会被收集到当前帧的输入中，交由 FrameHandler 的 OnFrame 方法处理
+		if r.collect != nil {
+			r.collect(event.Player, event.Message)
+			return nil
+		}
+		game.OnMessage(event.Player, event.Message)
+	case EventDequeue:
+		game.OnDequeue(event.Message)
+	case EventJoinRoom:
+		return r.joinRoom(game, event.Player)
+	case EventLeaveRoom:
+		r.leaveRoom(game, event.Player.GetId(), event.Error)
+	}
+	return nil
+}
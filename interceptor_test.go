@@ -0,0 +1,153 @@
+package newbee_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/smartwalle/newbee"
+	"github.com/smartwalle/newbee/newbeetest"
+)
+
+// eventLog 记录拦截器收到的事件类型
+type eventLog struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (l *eventLog) add(event string) {
+	l.mu.Lock()
+	l.events = append(l.events, event)
+	l.mu.Unlock()
+}
+
+func (l *eventLog) get() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.events...)
+}
+
+func (l *eventLog) interceptor(name string) newbee.Interceptor {
+	return func(next newbee.Handler) newbee.Handler {
+		return func(game newbee.Game, event newbee.Event) error {
+			l.add(name)
+			return next(game, event)
+		}
+	}
+}
+
+func TestInterceptorOrder(t *testing.T) {
+	var log = &eventLog{}
+	var game = newbeetest.NewGame(1)
+	var room = newbeetest.RunRoom(t, game, newbee.WithSync(), newbee.WithMessageInterceptor(log.interceptor("a"), log.interceptor("b")))
+	newbeetest.JoinPlayers(t, room, 1)
+
+	// 先添加的拦截器先执行
+	if got := log.get(); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("got %v, want [a b]", got)
+	}
+}
+
+func TestInterceptorDrop(t *testing.T) {
+	var errDenied = errors.New("denied")
+
+	var interceptor = func(next newbee.Handler) newbee.Handler {
+		return func(game newbee.Game, event newbee.Event) error {
+			switch event.Type {
+			case newbee.EventJoinRoom:
+				if event.Player.GetId() == 2 {
+					return errDenied
+				}
+			case newbee.EventDequeue:
+				if event.Message == "drop" {
+					return nil
+				}
+			}
+			return next(game, event)
+		}
+	}
+
+	for name, mode := range newbeetest.Modes() {
+		t.Run(name, func(t *testing.T) {
+			var game = newbeetest.NewGame(1)
+			var room = newbeetest.RunRoom(t, game, mode, newbee.WithMessageInterceptor(interceptor))
+
+			newbeetest.JoinPlayers(t, room, 1)
+			if err := room.AddPlayer(newbee.NewPlayer(2, newbeetest.NewSession())); err != errDenied {
+				t.Fatalf("got %v, want %v", err, errDenied)
+			}
+			if room.GetPlayer(2) != nil {
+				t.Fatal("rejected player was added to the room")
+			}
+
+			room.Enqueue("drop")
+			room.Enqueue("keep")
+			newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnDequeue")
+			if got := game.Calls()[2].Message; got != "keep" {
+				t.Fatalf("got %v, want keep", got)
+			}
+		})
+	}
+}
+
+func TestInterceptorLockstepInput(t *testing.T) {
+	var log = &eventLog{}
+	var interceptor = func(next newbee.Handler) newbee.Handler {
+		return func(game newbee.Game, event newbee.Event) error {
+			if event.Type == newbee.EventMessage {
+				log.add(packetData(event.Message))
+				if packetData(event.Message) == "cheat" {
+					return nil
+				}
+			}
+			return next(game, event)
+		}
+	}
+
+	var game = newbeetest.NewGame(1)
+	var room = newbeetest.RunRoom(t, game, newbee.WithLockstep(), newbee.WithMessageInterceptor(interceptor))
+	var sessions = newbeetest.JoinPlayers(t, room, 1)
+
+	sessions[0].Inject(newPacket("cheat"))
+	sessions[0].Inject(newPacket("move"))
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnFrame")
+
+	if got := log.get(); len(got) != 2 {
+		t.Fatalf("got %v, want both inputs to pass through the interceptor", got)
+	}
+	if got := packetData(game.Calls()[2].Message); got != "move" {
+		t.Fatalf("got %s, want move", got)
+	}
+}
+
+// panicGame 处理玩家消息的时候会产生异常
+type panicGame struct {
+	*newbeetest.Game
+}
+
+func (g panicGame) OnMessage(player newbee.Player, message interface{}) {
+	panic("bad message")
+}
+
+func TestInterceptorPanicLeave(t *testing.T) {
+	var log = &eventLog{}
+	var interceptor = func(next newbee.Handler) newbee.Handler {
+		return func(game newbee.Game, event newbee.Event) error {
+			if event.Type == newbee.EventLeaveRoom {
+				log.add(newbee.GetLeaveReason(event.Error).String())
+			}
+			return next(game, event)
+		}
+	}
+
+	var game = panicGame{newbeetest.NewGame(1)}
+	var room = newbeetest.RunRoom(t, game, newbee.WithSync(), newbee.WithMessageInterceptor(interceptor))
+	var sessions = newbeetest.JoinPlayers(t, room, 2)
+
+	sessions[0].Inject(newPacket("boom"))
+	newbeetest.ExpectCalls(t, game.Game, "OnRunInRoom", "OnJoinRoom", "OnJoinRoom", "OnPanic", "OnLeaveRoom", "OnLeaveRoom", "OnCloseRoom")
+
+	if got := log.get(); len(got) != 2 || got[0] != "shutdown" || got[1] != "shutdown" {
+		t.Fatalf("got %v, want two shutdown leaves through the interceptor", got)
+	}
+}
@@ -13,20 +13,33 @@ type message struct {
 	rError   chan<- error
 	Type     messageType
 	PlayerId int64
+	dropped  bool
 }
 
 type messageType int
 
 const (
-	mTypeDefault   messageType = 0
-	mTypePlayerIn  messageType = 1
-	mTypePlayerOut messageType = 2
-	mTypeTick      messageType = 3
-	mTypeCustom    messageType = 4
+	mTypeDefault          messageType = 0
+	mTypePlayerIn         messageType = 1
+	mTypePlayerOut        messageType = 2
+	mTypeTick             messageType = 3
+	mTypeCustom           messageType = 4
+	mTypePlayerDisconnect messageType = 5
+	mTypePlayerReconnect  messageType = 6
+	mTypeReconnectTimeout messageType = 7
+	mTypeObserverMessage  messageType = 8
+	mTypeObserverOut      messageType = 9
+	mTypeRateLimited      messageType = 10
+	mTypeCall             messageType = 11
+	mTypeTimer            messageType = 12
+	mTypeTickInterval     messageType = 13
+	mTypePause            messageType = 14
+	mTypeResume           messageType = 15
+	mTypeIdleCheck        messageType = 16
 )
 
 type iMessageQueue interface {
-	Enqueue(m *message)
+	Enqueue(m *message) bool
 
 	Dequeue(items *[]*message) bool
 
@@ -37,8 +50,8 @@ type blockMessageQueue struct {
 	bq block.Queue[*message]
 }
 
-func (q *blockMessageQueue) Enqueue(m *message) {
-	q.bq.Enqueue(m)
+func (q *blockMessageQueue) Enqueue(m *message) bool {
+	return q.bq.Enqueue(m)
 }
 
 func (q *blockMessageQueue) Dequeue(items *[]*message) bool {
@@ -61,9 +74,9 @@ type messageQueue struct {
 	closed   int32
 }
 
-func (q *messageQueue) Enqueue(m *message) {
+func (q *messageQueue) Enqueue(m *message) bool {
 	if atomic.LoadInt32(&q.closed) == 1 {
-		return
+		return false
 	}
 
 	q.mu.Lock()
@@ -79,6 +92,7 @@ func (q *messageQueue) Enqueue(m *message) {
 	q.elements[n] = m
 
 	q.mu.Unlock()
+	return true
 }
 
 func (q *messageQueue) Dequeue(elements *[]*message) bool {
@@ -107,3 +121,122 @@ func newQueue() *messageQueue {
 	q.elements = make([]*message, 0, 32)
 	return q
 }
+
+type QueuePolicy int
+
+const (
+	QueuePolicyDropNewest QueuePolicy = iota // 丢弃新收到的消息
+	QueuePolicyDropOldest                    // 丢弃队列中最早的消息
+	QueuePolicyBlock                         // 阻塞发送消息的连接，直到队列有空闲位置，机器人（Bot 的 Input 方法）的消息不会阻塞，队列已满的时候直接丢弃
+	QueuePolicyKick                          // 丢弃新收到的消息，并断开发送该消息的连接
+)
+
+// bounded 只限制网络消息的数量，玩家加入、离开以及定时器等消息不受限制
+func (m *message) bounded() bool {
+	return m.Type == mTypeDefault || m.Type == mTypeObserverMessage || m.Type == mTypeRateLimited
+}
+
+// boundedMessageQueue 为其它队列提供容量限制
+type boundedMessageQueue struct {
+	queue    iMessageQueue
+	release  func(m *message)
+	cond     *sync.Cond
+	pending  []*message
+	capacity int
+	policy   QueuePolicy
+	dropped  uint64
+	closed   bool
+}
+
+func (q *boundedMessageQueue) Enqueue(m *message) bool {
+	return q.enqueue(m, true)
+}
+
+// TryEnqueue 和 Enqueue 相同，但是 QueuePolicyBlock 策略下队列已满的时候不会阻塞，而是丢弃消息
+func (q *boundedMessageQueue) TryEnqueue(m *message) bool {
+	return q.enqueue(m, false)
+}
+
+func (q *boundedMessageQueue) enqueue(m *message, wait bool) bool {
+	if !m.bounded() {
+		return q.queue.Enqueue(m)
+	}
+
+	q.cond.L.Lock()
+	for wait && q.policy == QueuePolicyBlock && len(q.pending) >= q.capacity && !q.closed {
+		q.cond.Wait()
+	}
+
+	if q.closed {
+		q.cond.L.Unlock()
+		return false
+	}
+
+	if len(q.pending) >= q.capacity {
+		if q.policy != QueuePolicyDropOldest {
+			q.cond.L.Unlock()
+			atomic.AddUint64(&q.dropped, 1)
+			return false
+		}
+
+		// 队列中的消息只做标记，在出队的时候再丢弃
+		q.pending[0].dropped = true
+		q.pending = q.pending[1:]
+		atomic.AddUint64(&q.dropped, 1)
+	}
+
+	q.pending = append(q.pending, m)
+	var ok = q.queue.Enqueue(m)
+	q.cond.L.Unlock()
+	return ok
+}
+
+func (q *boundedMessageQueue) Dequeue(items *[]*message) bool {
+	var ok = q.queue.Dequeue(items)
+
+	q.cond.L.Lock()
+	var nItems = (*items)[:0]
+	for _, m := range *items {
+		if m.bounded() {
+			if m.dropped {
+				q.release(m)
+				continue
+			}
+			q.pending = q.pending[1:]
+		}
+		nItems = append(nItems, m)
+	}
+	*items = nItems
+	q.cond.L.Unlock()
+	q.cond.Broadcast()
+
+	return ok
+}
+
+func (q *boundedMessageQueue) Close() {
+	q.cond.L.Lock()
+	q.closed = true
+	q.cond.L.Unlock()
+	q.cond.Broadcast()
+
+	q.queue.Close()
+}
+
+// drop 记录在队列之外被丢弃的消息，例如房间暂停期间缓存的玩家消息
+func (q *boundedMessageQueue) drop() {
+	atomic.AddUint64(&q.dropped, 1)
+}
+
+func (q *boundedMessageQueue) Dropped() uint64 {
+	return atomic.LoadUint64(&q.dropped)
+}
+
+func newBoundedQueue(queue iMessageQueue, capacity int, policy QueuePolicy, release func(m *message)) *boundedMessageQueue {
+	var q = &boundedMessageQueue{}
+	q.queue = queue
+	q.release = release
+	q.cond = sync.NewCond(&sync.Mutex{})
+	q.capacity = capacity
+	q.policy = policy
+	return q
+}
@@ -0,0 +1,145 @@
+package newbee_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/smartwalle/newbee"
+	"github.com/smartwalle/newbee/newbeetest"
+)
+
+// blockGame 在 OnCall 中阻塞 Room 的消息处理协程，直到 release 被关闭
+type blockGame struct {
+	*newbeetest.Game
+	entered chan struct{}
+	release chan struct{}
+	panics  bool // 为 true 的时候，OnCall 在 release 被关闭之后产生异常
+}
+
+func newBlockGame() *blockGame {
+	var g = &blockGame{}
+	g.Game = newbeetest.NewGame(1)
+	g.Interval = 0
+	g.entered = make(chan struct{})
+	g.release = make(chan struct{})
+	return g
+}
+
+func (g *blockGame) OnCall(request interface{}) (interface{}, error) {
+	close(g.entered)
+	<-g.release
+	if g.panics {
+		panic("call")
+	}
+	return nil, nil
+}
+
+// block 阻塞 Room 的消息处理协程，返回之后 Room 不会再从队列中取出消息
+func (g *blockGame) block(room newbee.Room) {
+	go room.Call(context.Background(), nil)
+	<-g.entered
+}
+
+func TestQueuePolicy(t *testing.T) {
+	var tests = []struct {
+		name    string
+		policy  newbee.QueuePolicy
+		want    []string
+		dropped uint64
+	}{
+		{name: "drop newest", policy: newbee.QueuePolicyDropNewest, want: []string{"a", "b"}, dropped: 2},
+		{name: "drop oldest", policy: newbee.QueuePolicyDropOldest, want: []string{"c", "d"}, dropped: 2},
+		{name: "block", policy: newbee.QueuePolicyBlock, want: []string{"a", "b", "c", "d"}, dropped: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var game = newBlockGame()
+			var room = newbeetest.RunRoom(t, game, newbee.WithAsync(), newbee.WithQueueCapacity(2, test.policy))
+			var sessions = newbeetest.JoinPlayers(t, room, 1)
+
+			game.block(room)
+
+			var injected = make(chan struct{})
+			go func() {
+				for _, data := range []string{"a", "b", "c", "d"} {
+					sessions[0].Inject(newPacket(data))
+				}
+				close(injected)
+			}()
+
+			if test.policy == newbee.QueuePolicyBlock {
+				// 队列已满，Inject 会阻塞到 Room 继续处理消息
+				select {
+				case <-injected:
+					t.Fatal("Inject did not block on a full queue")
+				case <-time.After(time.Millisecond * 20):
+				}
+			} else {
+				<-injected
+			}
+			close(game.release)
+			<-injected
+
+			var names = []string{"OnRunInRoom", "OnJoinRoom"}
+			for range test.want {
+				names = append(names, "OnMessage")
+			}
+			newbeetest.ExpectCalls(t, game.Game, names...)
+
+			for i, call := range game.Calls()[2:] {
+				if got := packetData(call.Message); got != test.want[i] {
+					t.Fatalf("message %d: got %s, want %s", i, got, test.want[i])
+				}
+			}
+			if n := room.GetDroppedCount(); n != test.dropped {
+				t.Fatalf("got %d dropped messages, want %d", n, test.dropped)
+			}
+		})
+	}
+}
+
+func TestQueuePolicyKick(t *testing.T) {
+	var game = newBlockGame()
+	var room = newbeetest.RunRoom(t, game, newbee.WithAsync(), newbee.WithQueueCapacity(2, newbee.QueuePolicyKick))
+	var sessions = newbeetest.JoinPlayers(t, room, 1)
+
+	game.block(room)
+	for _, data := range []string{"a", "b", "c"} {
+		sessions[0].Inject(newPacket(data))
+	}
+	if !sessions[0].Closed() {
+		t.Fatal("session was not closed when the queue was full")
+	}
+	close(game.release)
+
+	newbeetest.ExpectCalls(t, game.Game, "OnRunInRoom", "OnJoinRoom", "OnMessage", "OnMessage", "OnLeaveRoom")
+	if n := room.GetDroppedCount(); n != 1 {
+		t.Fatalf("got %d dropped messages, want 1", n)
+	}
+}
+
+func TestQueuePolicyBlockPanic(t *testing.T) {
+	var game = newBlockGame()
+	game.panics = true
+	var room = newbeetest.RunRoom(t, game, newbee.WithAsync(), newbee.WithQueueCapacity(1, newbee.QueuePolicyBlock))
+	var sessions = newbeetest.JoinPlayers(t, room, 1)
+
+	game.block(room)
+	sessions[0].Inject(newPacket("a"))
+
+	var injected = make(chan bool)
+	go func() {
+		injected <- sessions[0].Inject(newPacket("b"))
+	}()
+
+	// 房间因为异常关闭之后，阻塞的连接需要被唤醒
+	close(game.release)
+	newbeetest.ExpectCalls(t, game.Game, "OnRunInRoom", "OnJoinRoom", "OnPanic", "OnLeaveRoom", "OnCloseRoom")
+	select {
+	case <-injected:
+	case <-time.After(time.Second):
+		t.Fatal("Inject is still blocked after the room panicked")
+	}
+}
@@ -0,0 +1,115 @@
+package newbee
+
+import (
+	"github.com/smartwalle/net4go"
+	"io"
+	"time"
+)
+
+// Metrics 房间运行指标收集器，通过 WithMetrics 设置，多个房间可以共用同一个 Metrics
+// 除了 AddBytesSent 之外，所有方法都会带上房间 id，Metrics 的实现需要保证并发安全
+type Metrics interface {
+	// ObserveQueueDepth 记录 Room 每次从队列中取出的消息数量，即取出消息时队列的深度
+	ObserveQueueDepth(roomId int64, depth int)
+
+	// IncMessage 记录 Room 处理的消息，mType 为消息类型，如 default、custom、player_in 等
+	IncMessage(roomId int64, mType string)
+
+	// ObserveTick 记录 Game 的 OnTick 方法的执行时间，以及本次 OnTick 和上一次 OnTick 的实际间隔与 TickInterval 的差值
+	ObserveTick(roomId int64, duration, lag time.Duration)
+
+	// IncTickOverrun 记录超时的 tick，skipped 为因此跳过的 tick 数量
+	IncTickOverrun(roomId int64, skipped int)
+
+	// SetPlayerCount 记录房间的玩家数量
+	SetPlayerCount(roomId int64, count int)
+
+	// IncPanic 记录房间发生的未捕获异常
+	IncPanic(roomId int64)
+
+	// AddBytesSent 记录发送的字节数，需要配合 NewMetricsProtocol 使用
+	AddBytesSent(n int)
+
+	// RemoveRoom 房间结束之后会调用此方法，用于清理房间相关的指标（例如队列长度和玩家数量），房间结束之前调用的 IncPanic 等方法产生的计数不应该被清理
+	RemoveRoom(roomId int64)
+}
+
+func (t messageType) String() string {
+	switch t {
+	case mTypeDefault:
+		return "default"
+	case mTypePlayerIn:
+		return "player_in"
+	case mTypePlayerOut:
+		return "player_out"
+	case mTypeTick:
+		return "tick"
+	case mTypeCustom:
+		return "custom"
+	case mTypePlayerDisconnect:
+		return "player_disconnect"
+	case mTypePlayerReconnect:
+		return "player_reconnect"
+	case mTypeReconnectTimeout:
+		return "reconnect_timeout"
+	case mTypeObserverMessage:
+		return "observer_message"
+	case mTypeObserverOut:
+		return "observer_out"
+	case mTypeRateLimited:
+		return "rate_limited"
+	case mTypeCall:
+		return "call"
+	case mTypeTimer:
+		return "timer"
+	case mTypeTickInterval:
+		return "tick_interval"
+	case mTypePause:
+		return "pause"
+	case mTypeResume:
+		return "resume"
+	case mTypeIdleCheck:
+		return "idle_check"
+	}
+	return "unknown"
+}
+
+// observeDequeue 记录从队列中取出的消息
+func (r *room) observeDequeue(mList []*message) {
+	if r.metrics == nil || len(mList) == 0 {
+		return
+	}
+	r.metrics.ObserveQueueDepth(r.id, len(mList))
+	for _, m := range mList {
+		r.metrics.IncMessage(r.id, m.Type.String())
+	}
+}
+
+func (r *room) observePlayerCount() {
+	if r.metrics == nil {
+		return
+	}
+	r.metrics.SetPlayerCount(r.id, r.GetPlayerCount())
+}
+
+type metricsProtocol struct {
+	net4go.Protocol
+	metrics Metrics
+}
+
+// NewMetricsProtocol 包装 net4go.Protocol，统计通过该协议发送的字节数
+func NewMetricsProtocol(protocol net4go.Protocol, metrics Metrics) net4go.Protocol {
+	return &metricsProtocol{Protocol: protocol, metrics: metrics}
+}
+
+func (p *metricsProtocol) Marshal(packet net4go.Packet) ([]byte, error) {
+	var data, err = p.Protocol.Marshal(packet)
+	if err == nil {
+		p.metrics.AddBytesSent(len(data))
+	}
+	return data, err
+}
+
+func (p *metricsProtocol) Unmarshal(r io.Reader) (net4go.Packet, error) {
+	return p.Protocol.Unmarshal(r)
+}
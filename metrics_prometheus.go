@@ -0,0 +1,194 @@
+package newbee
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultTickBuckets OnTick 执行时间直方图默认的分桶（单位：秒）
+var DefaultTickBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}
+
+// roomMetrics 房间相关的指标，房间结束之后（RemoveRoom）会被清理
+type roomMetrics struct {
+	tickBuckets []uint64
+	tickSum     float64
+	tickCount   uint64
+	tickLag     float64
+	queueDepth  int
+	players     int
+}
+
+// PrometheusMetrics 内置的 Metrics 实现，实现了 http.Handler 接口，以 Prometheus 文本格式输出所有指标
+// 计数器（newbee_*_total）不区分房间，房间结束之后不会被清理，避免房间关闭的时候丢失计数（例如房间因为异常关闭的时候的 newbee_panics_total）
+//
+//	var metrics = newbee.NewPrometheusMetrics()
+//	http.Handle("/metrics", metrics)
+//	var room = newbee.NewRoom(1, newbee.WithMetrics(metrics))
+type PrometheusMetrics struct {
+	rooms     map[int64]*roomMetrics
+	buckets   []float64
+	messages  map[string]uint64
+	overruns  uint64
+	skipped   uint64
+	panics    uint64
+	bytesSent uint64
+	mu        sync.Mutex
+}
+
+// NewPrometheusMetrics 创建 PrometheusMetrics，buckets 为 OnTick 执行时间直方图的分桶（单位：秒），为空时使用 DefaultTickBuckets
+func NewPrometheusMetrics(buckets ...float64) *PrometheusMetrics {
+	if len(buckets) == 0 {
+		buckets = DefaultTickBuckets
+	}
+	var m = &PrometheusMetrics{}
+	m.rooms = make(map[int64]*roomMetrics)
+	m.messages = make(map[string]uint64)
+	m.buckets = append([]float64(nil), buckets...)
+	sort.Float64s(m.buckets)
+	return m
+}
+
+func (m *PrometheusMetrics) room(roomId int64) *roomMetrics {
+	var rm = m.rooms[roomId]
+	if rm == nil {
+		rm = &roomMetrics{}
+		rm.tickBuckets = make([]uint64, len(m.buckets))
+		m.rooms[roomId] = rm
+	}
+	return rm
+}
+
+func (m *PrometheusMetrics) ObserveQueueDepth(roomId int64, depth int) {
+	m.mu.Lock()
+	m.room(roomId).queueDepth = depth
+	m.mu.Unlock()
+}
+
+func (m *PrometheusMetrics) IncMessage(roomId int64, mType string) {
+	m.mu.Lock()
+	m.messages[mType]++
+	m.mu.Unlock()
+}
+
+func (m *PrometheusMetrics) ObserveTick(roomId int64, duration, lag time.Duration) {
+	var seconds = duration.Seconds()
+
+	m.mu.Lock()
+	var rm = m.room(roomId)
+	for i, bound := range m.buckets {
+		if seconds <= bound {
+			rm.tickBuckets[i]++
+		}
+	}
+	rm.tickSum += seconds
+	rm.tickCount++
+	rm.tickLag = lag.Seconds()
+	m.mu.Unlock()
+}
+
+func (m *PrometheusMetrics) IncTickOverrun(roomId int64, skipped int) {
+	m.mu.Lock()
+	m.overruns++
+	m.skipped += uint64(skipped)
+	m.mu.Unlock()
+}
+
+func (m *PrometheusMetrics) SetPlayerCount(roomId int64, count int) {
+	m.mu.Lock()
+	m.room(roomId).players = count
+	m.mu.Unlock()
+}
+
+func (m *PrometheusMetrics) IncPanic(roomId int64) {
+	m.mu.Lock()
+	m.panics++
+	m.mu.Unlock()
+}
+
+func (m *PrometheusMetrics) AddBytesSent(n int) {
+	m.mu.Lock()
+	m.bytesSent += uint64(n)
+	m.mu.Unlock()
+}
+
+func (m *PrometheusMetrics) RemoveRoom(roomId int64) {
+	m.mu.Lock()
+	delete(m.rooms, roomId)
+	m.mu.Unlock()
+}
+
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	var bw = bufio.NewWriter(w)
+	m.writeTo(bw)
+	bw.Flush()
+}
+
+func (m *PrometheusMetrics) writeTo(w *bufio.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var roomIds = make([]int64, 0, len(m.rooms))
+	for roomId := range m.rooms {
+		roomIds = append(roomIds, roomId)
+	}
+	sort.Slice(roomIds, func(i, j int) bool { return roomIds[i] < roomIds[j] })
+
+	writeHeader(w, "newbee_queue_depth", "gauge", "Number of messages dequeued in the last batch.")
+	for _, roomId := range roomIds {
+		fmt.Fprintf(w, "newbee_queue_depth{room=\"%d\"} %d\n", roomId, m.rooms[roomId].queueDepth)
+	}
+
+	writeHeader(w, "newbee_messages_total", "counter", "Number of messages dequeued by type.")
+	var types = make([]string, 0, len(m.messages))
+	for mType := range m.messages {
+		types = append(types, mType)
+	}
+	sort.Strings(types)
+	for _, mType := range types {
+		fmt.Fprintf(w, "newbee_messages_total{type=\"%s\"} %d\n", mType, m.messages[mType])
+	}
+
+	writeHeader(w, "newbee_tick_duration_seconds", "histogram", "Duration of Game.OnTick.")
+	for _, roomId := range roomIds {
+		var rm = m.rooms[roomId]
+		for i, bound := range m.buckets {
+			fmt.Fprintf(w, "newbee_tick_duration_seconds_bucket{room=\"%d\",le=\"%g\"} %d\n", roomId, bound, rm.tickBuckets[i])
+		}
+		fmt.Fprintf(w, "newbee_tick_duration_seconds_bucket{room=\"%d\",le=\"+Inf\"} %d\n", roomId, rm.tickCount)
+		fmt.Fprintf(w, "newbee_tick_duration_seconds_sum{room=\"%d\"} %g\n", roomId, rm.tickSum)
+		fmt.Fprintf(w, "newbee_tick_duration_seconds_count{room=\"%d\"} %d\n", roomId, rm.tickCount)
+	}
+
+	writeHeader(w, "newbee_tick_lag_seconds", "gauge", "Difference between the actual and the configured tick interval of the last tick.")
+	for _, roomId := range roomIds {
+		fmt.Fprintf(w, "newbee_tick_lag_seconds{room=\"%d\"} %g\n", roomId, m.rooms[roomId].tickLag)
+	}
+
+	writeHeader(w, "newbee_tick_overruns_total", "counter", "Number of ticks that took longer than the configured interval.")
+	fmt.Fprintf(w, "newbee_tick_overruns_total %d\n", m.overruns)
+
+	writeHeader(w, "newbee_ticks_skipped_total", "counter", "Number of ticks skipped by TickPolicySkip.")
+	fmt.Fprintf(w, "newbee_ticks_skipped_total %d\n", m.skipped)
+
+	writeHeader(w, "newbee_players", "gauge", "Number of players in the room.")
+	for _, roomId := range roomIds {
+		fmt.Fprintf(w, "newbee_players{room=\"%d\"} %d\n", roomId, m.rooms[roomId].players)
+	}
+
+	writeHeader(w, "newbee_panics_total", "counter", "Number of recovered panics.")
+	fmt.Fprintf(w, "newbee_panics_total %d\n", m.panics)
+
+	writeHeader(w, "newbee_bytes_sent_total", "counter", "Number of bytes sent through NewMetricsProtocol.")
+	fmt.Fprintf(w, "newbee_bytes_sent_total %d\n", m.bytesSent)
+}
+
+func writeHeader(w *bufio.Writer, name, mType, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, mType)
+}
@@ -0,0 +1,89 @@
+package newbee_test
+
+import (
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/smartwalle/net4go"
+	"github.com/smartwalle/newbee"
+	"github.com/smartwalle/newbee/newbeetest"
+)
+
+// scrape 通过 HTTP 获取 metrics 输出的所有指标
+func scrape(t *testing.T, metrics *newbee.PrometheusMetrics) string {
+	t.Helper()
+
+	var server = httptest.NewServer(metrics)
+	defer server.Close()
+
+	var rsp, err = server.Client().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rsp.Body.Close()
+
+	body, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestPrometheusMetrics(t *testing.T) {
+	var metrics = newbee.NewPrometheusMetrics()
+	var game = newbeetest.NewGame(1)
+	var room = newbeetest.RunRoom(t, game, newbee.WithAsync(), newbee.WithMetrics(metrics))
+	var sessions = newbeetest.JoinPlayers(t, room, 2)
+
+	sessions[0].Inject(newPacket("a"))
+	room.Enqueue("custom")
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnJoinRoom", "OnMessage", "OnDequeue")
+	waitFor(t, "ticks", func() bool { return game.Ticks() > 0 })
+
+	var protocol = newbee.NewMetricsProtocol(&net4go.DefaultProtocol{}, metrics)
+	data, err := protocol.Marshal(newPacket("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body = scrape(t, metrics)
+	for _, want := range []string{
+		`newbee_players{room="1"} 2`,
+		`newbee_messages_total{type="player_in"} 2`,
+		`newbee_messages_total{type="default"} 1`,
+		`newbee_messages_total{type="custom"} 1`,
+		`newbee_tick_duration_seconds_count{room="1"}`,
+		"newbee_bytes_sent_total " + strconv.Itoa(len(data)),
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("metrics do not contain %q:\n%s", want, body)
+		}
+	}
+
+	// 房间关闭之后会清理房间相关的指标
+	room.Close()
+	waitFor(t, "the room metrics to be removed", func() bool {
+		return !strings.Contains(scrape(t, metrics), `room="1"`)
+	})
+}
+
+func TestPrometheusMetricsPanic(t *testing.T) {
+	var metrics = newbee.NewPrometheusMetrics()
+	var game = panicGame{newbeetest.NewGame(1)}
+	var room = newbeetest.RunRoom(t, game, newbee.WithAsync(), newbee.WithMetrics(metrics))
+	var sessions = newbeetest.JoinPlayers(t, room, 1)
+
+	sessions[0].Inject(newPacket("panic"))
+	newbeetest.ExpectCalls(t, game.Game, "OnRunInRoom", "OnJoinRoom", "OnPanic", "OnLeaveRoom", "OnCloseRoom")
+
+	// 房间因为异常关闭之后依然可以获取异常的次数
+	waitFor(t, "the room metrics to be removed", func() bool {
+		return !strings.Contains(scrape(t, metrics), `room="1"`)
+	})
+	if body := scrape(t, metrics); !strings.Contains(body, "newbee_panics_total 1") {
+		t.Fatalf("metrics do not contain the panic:\n%s", body)
+	}
+}
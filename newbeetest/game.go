@@ -0,0 +1,209 @@
+package newbeetest
+
+import (
+	"github.com/smartwalle/net4go"
+	"github.com/smartwalle/newbee"
+	"sync"
+	"time"
+)
+
+// Call Game 的一次回调
+type Call struct {
+	Name     string      // 回调方法名称，如 OnJoinRoom
+	PlayerId int64       // 玩家（或者观察者） id
+	Message  interface{} // 消息内容
+	Error    error       // 玩家离开房间或者断线的原因
+}
+
+// Game 记录所有回调的 newbee.Game 实现，同时实现了 ReconnectHandler、ObserverHandler、RateLimitHandler、PauseHandler、StateChangeHandler、IdleHandler 和 FrameHandler
+// OnTick 每个周期都会触发，只记录次数，不会出现在 Calls 中
+// OnFrame 除了记录次数之外，会为本帧收集到的每一条玩家输入记录一次名称为 OnFrame 的回调
+type Game struct {
+	Interval time.Duration // TickInterval 的返回值，需要在房间运行之前设置
+
+	room   newbee.Room
+	state  newbee.GameState
+	cond   *sync.Cond
+	calls  []Call
+	ticks  int
+	frames int
+	id     int64
+}
+
+// NewGame 创建 Game，TickInterval 默认为 10 毫秒
+func NewGame(id int64) *Game {
+	var g = &Game{}
+	g.id = id
+	g.Interval = time.Millisecond * 10
+	g.cond = sync.NewCond(&sync.Mutex{})
+	return g
+}
+
+func (g *Game) record(call Call) {
+	g.cond.L.Lock()
+	g.calls = append(g.calls, call)
+	g.cond.L.Unlock()
+	g.cond.Broadcast()
+}
+
+// Calls 获取已记录的回调
+func (g *Game) Calls() []Call {
+	g.cond.L.Lock()
+	var calls = make([]Call, len(g.calls))
+	copy(calls, g.calls)
+	g.cond.L.Unlock()
+	return calls
+}
+
+// Names 获取已记录的回调的方法名称
+func (g *Game) Names() []string {
+	var calls = g.Calls()
+	var names = make([]string, 0, len(calls))
+	for _, call := range calls {
+		names = append(names, call.Name)
+	}
+	return names
+}
+
+// Wait 等待记录的回调数量达到 n，超时返回 false
+func (g *Game) Wait(n int, timeout time.Duration) bool {
+	var deadline = time.Now().Add(timeout)
+	var timer = time.AfterFunc(timeout, g.cond.Broadcast)
+	defer timer.Stop()
+
+	g.cond.L.Lock()
+	defer g.cond.L.Unlock()
+	for len(g.calls) < n {
+		if !time.Now().Before(deadline) {
+			return false
+		}
+		g.cond.Wait()
+	}
+	return true
+}
+
+// Ticks 获取 OnTick 被调用的次数
+func (g *Game) Ticks() int {
+	g.cond.L.Lock()
+	defer g.cond.L.Unlock()
+	return g.ticks
+}
+
+// Frames 获取 OnFrame 被调用的次数
+func (g *Game) Frames() int {
+	g.cond.L.Lock()
+	defer g.cond.L.Unlock()
+	return g.frames
+}
+
+// Room 获取 OnRunInRoom 传入的 Room
+func (g *Game) Room() newbee.Room {
+	g.cond.L.Lock()
+	defer g.cond.L.Unlock()
+	return g.room
+}
+
+func (g *Game) GetId() int64 {
+	return g.id
+}
+
+func (g *Game) GetState() newbee.GameState {
+	g.cond.L.Lock()
+	defer g.cond.L.Unlock()
+	return g.state
+}
+
+// SetState 修改 GetState 的返回值，可以在任意协程中调用，Room 会在下一次调用 Game 的回调方法之后检查状态的变化
+func (g *Game) SetState(state newbee.GameState) {
+	g.cond.L.Lock()
+	g.state = state
+	g.cond.L.Unlock()
+}
+
+func (g *Game) TickInterval() time.Duration {
+	return g.Interval
+}
+
+func (g *Game) OnTick() {
+	g.cond.L.Lock()
+	g.ticks++
+	g.cond.L.Unlock()
+	g.cond.Broadcast()
+}
+
+func (g *Game) OnMessage(player newbee.Player, message interface{}) {
+	g.record(Call{Name: "OnMessage", PlayerId: player.GetId(), Message: message})
+}
+
+func (g *Game) OnDequeue(message interface{}) {
+	g.record(Call{Name: "OnDequeue", Message: message})
+}
+
+func (g *Game) OnRunInRoom(room newbee.Room) {
+	g.cond.L.Lock()
+	g.room = room
+	g.cond.L.Unlock()
+	g.record(Call{Name: "OnRunInRoom"})
+}
+
+func (g *Game) OnJoinRoom(player newbee.Player) {
+	g.record(Call{Name: "OnJoinRoom", PlayerId: player.GetId()})
+}
+
+func (g *Game) OnLeaveRoom(player newbee.Player, err error) {
+	g.record(Call{Name: "OnLeaveRoom", PlayerId: player.GetId(), Error: err})
+}
+
+func (g *Game) OnCloseRoom(room newbee.Room) {
+	g.record(Call{Name: "OnCloseRoom"})
+}
+
+func (g *Game) OnPanic(room newbee.Room, err error) {
+	g.record(Call{Name: "OnPanic", Error: err})
+}
+
+func (g *Game) OnPlayerDisconnected(player newbee.Player, err error) {
+	g.record(Call{Name: "OnPlayerDisconnected", PlayerId: player.GetId(), Error: err})
+}
+
+func (g *Game) OnPlayerReconnected(player newbee.Player) {
+	g.record(Call{Name: "OnPlayerReconnected", PlayerId: player.GetId()})
+}
+
+func (g *Game) OnObserverMessage(observer newbee.Player, message interface{}) {
+	g.record(Call{Name: "OnObserverMessage", PlayerId: observer.GetId(), Message: message})
+}
+
+func (g *Game) OnRateLimited(player newbee.Player, message interface{}) {
+	g.record(Call{Name: "OnRateLimited", PlayerId: player.GetId(), Message: message})
+}
+
+func (g *Game) OnPause() {
+	g.record(Call{Name: "OnPause"})
+}
+
+func (g *Game) OnResume() {
+	g.record(Call{Name: "OnResume"})
+}
+
+func (g *Game) OnStateChange(from, to newbee.GameState) {
+	g.record(Call{Name: "OnStateChange", Message: [2]newbee.GameState{from, to}})
+}
+
+func (g *Game) OnPlayerIdle(player newbee.Player, idle time.Duration) {
+	g.record(Call{Name: "OnPlayerIdle", PlayerId: player.GetId(), Message: idle})
+}
+
+func (g *Game) OnFrame(frameId uint64, inputs map[int64][]interface{}) net4go.Packet {
+	g.cond.L.Lock()
+	g.frames++
+	g.cond.L.Unlock()
+	g.cond.Broadcast()
+
+	for playerId, messages := range inputs {
+		for _, message := range messages {
+			g.record(Call{Name: "OnFrame", PlayerId: playerId, Message: message})
+		}
+	}
+	return nil
+}
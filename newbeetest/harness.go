@@ -0,0 +1,76 @@
+package newbeetest
+
+import (
+	"context"
+	"github.com/smartwalle/newbee"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Modes 返回所有的运行模式，用于编写表格驱动的测试
+func Modes() map[string]newbee.RoomOption {
+	return map[string]newbee.RoomOption{
+		"async":    newbee.WithAsync(),
+		"sync":     newbee.WithSync(),
+		"frame":    newbee.WithFrame(),
+		"lockstep": newbee.WithLockstep(),
+	}
+}
+
+// RunRoom 创建并运行房间，返回之后房间已经处于 RoomStateRunning 状态，测试结束的时候会自动关闭房间
+func RunRoom(t testing.TB, game newbee.Game, opts ...newbee.RoomOption) newbee.Room {
+	t.Helper()
+
+	var room = newbee.NewRoom(game.GetId(), opts...)
+	var rErr = make(chan error, 1)
+
+	go func() {
+		rErr <- room.Run(game)
+	}()
+
+	for room.GetState() != newbee.RoomStateRunning {
+		select {
+		case err := <-rErr:
+			t.Fatalf("newbeetest: failed to run the room: %v", err)
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	t.Cleanup(func() {
+		var ctx, cancel = context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+		if err := room.Shutdown(ctx); err != nil {
+			t.Errorf("newbeetest: failed to shutdown the room: %v", err)
+		}
+	})
+	return room
+}
+
+// JoinPlayers 向房间中添加 n 个玩家，玩家 id 从 1 开始，返回每个玩家对应的 Session
+func JoinPlayers(t testing.TB, room newbee.Room, n int) []*Session {
+	t.Helper()
+
+	var sessions = make([]*Session, 0, n)
+	for i := 1; i <= n; i++ {
+		var sess = NewSession()
+		if err := room.AddPlayer(newbee.NewPlayer(int64(i), sess)); err != nil {
+			t.Fatalf("newbeetest: failed to add player %d: %v", i, err)
+		}
+		sessions = append(sessions, sess)
+	}
+	return sessions
+}
+
+// ExpectCalls 等待 game 记录的回调数量达到 len(names)，并检查回调的方法名称和顺序
+func ExpectCalls(t testing.TB, game *Game, names ...string) {
+	t.Helper()
+
+	if !game.Wait(len(names), time.Second) {
+		t.Fatalf("newbeetest: timed out waiting for calls, got %v, want %v", game.Names(), names)
+	}
+
+	if got := game.Names(); !reflect.DeepEqual(got, names) {
+		t.Fatalf("newbeetest: unexpected calls, got %v, want %v", got, names)
+	}
+}
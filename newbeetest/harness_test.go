@@ -0,0 +1,110 @@
+package newbeetest_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/smartwalle/net4go"
+	"github.com/smartwalle/newbee"
+	"github.com/smartwalle/newbee/newbeetest"
+)
+
+func TestRunRoom(t *testing.T) {
+	for name, mode := range newbeetest.Modes() {
+		t.Run(name, func(t *testing.T) {
+			var game = newbeetest.NewGame(1)
+			var room = newbeetest.RunRoom(t, game, mode)
+
+			if room.GetState() != newbee.RoomStateRunning {
+				t.Fatalf("got state %d, want %d", room.GetState(), newbee.RoomStateRunning)
+			}
+			if game.Room() != room {
+				t.Fatal("OnRunInRoom received a different room")
+			}
+
+			newbeetest.JoinPlayers(t, room, 2)
+			newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnJoinRoom")
+
+			if room.GetPlayerCount() != 2 {
+				t.Fatalf("got %d players, want 2", room.GetPlayerCount())
+			}
+		})
+	}
+}
+
+func TestSessionInject(t *testing.T) {
+	for name, mode := range newbeetest.Modes() {
+		if name == "lockstep" {
+			continue
+		}
+
+		t.Run(name, func(t *testing.T) {
+			var game = newbeetest.NewGame(1)
+			var room = newbeetest.RunRoom(t, game, mode)
+			var sessions = newbeetest.JoinPlayers(t, room, 2)
+
+			var packet = net4go.NewDefaultPacket(1, []byte("hello"))
+			if !sessions[1].Inject(packet) {
+				t.Fatal("Inject returned false for a joined session")
+			}
+			newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnJoinRoom", "OnMessage")
+
+			var call = game.Calls()[3]
+			if call.PlayerId != 2 || call.Message != net4go.Packet(packet) {
+				t.Fatalf("got %+v, want a message from player 2", call)
+			}
+		})
+	}
+}
+
+func TestSessionPackets(t *testing.T) {
+	var game = newbeetest.NewGame(1)
+	var room = newbeetest.RunRoom(t, game, newbee.WithSync())
+	var sessions = newbeetest.JoinPlayers(t, room, 2)
+
+	var packet = net4go.NewDefaultPacket(1, nil)
+	room.SendPacket(1, packet)
+	room.BroadcastPacket(packet)
+
+	if n := len(sessions[0].Packets()); n != 2 {
+		t.Fatalf("player 1 got %d packets, want 2", n)
+	}
+	if n := len(sessions[1].Packets()); n != 1 {
+		t.Fatalf("player 2 got %d packets, want 1", n)
+	}
+}
+
+func TestSessionCloseWithError(t *testing.T) {
+	var game = newbeetest.NewGame(1)
+	var room = newbeetest.RunRoom(t, game, newbee.WithAsync())
+	var sessions = newbeetest.JoinPlayers(t, room, 1)
+
+	var errBroken = errors.New("broken pipe")
+	sessions[0].CloseWithError(errBroken)
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnLeaveRoom")
+
+	var err = game.Calls()[2].Error
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("got %v, want it to wrap %v", err, errBroken)
+	}
+	if reason := newbee.GetLeaveReason(err); reason != newbee.LeaveReasonDisconnect {
+		t.Fatalf("got reason %s, want %s", reason, newbee.LeaveReasonDisconnect)
+	}
+	if sessions[0].Inject(net4go.NewDefaultPacket(1, nil)) {
+		t.Fatal("Inject returned true for a closed session")
+	}
+}
+
+func TestGameSetState(t *testing.T) {
+	var game = newbeetest.NewGame(1)
+	game.Interval = 0 // 禁用 tick，只在 OnDequeue 之后检查状态
+	var room = newbeetest.RunRoom(t, game, newbee.WithAsync(), newbee.WithGameOverPolicy(newbee.GameOverPolicyPending))
+
+	game.SetState(newbee.GameStateGaming)
+	room.Enqueue("check")
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnDequeue", "OnStateChange")
+
+	if got := game.Calls()[2].Message; got != [2]newbee.GameState{newbee.GameStatePending, newbee.GameStateGaming} {
+		t.Fatalf("got %v, want [pending gaming]", got)
+	}
+}
@@ -0,0 +1,129 @@
+package newbeetest
+
+import (
+	"github.com/smartwalle/net4go"
+	"sync"
+)
+
+// Session 内存中的 net4go.Session 实现，不需要真实的网络连接
+// 写入的消息会被记录下来，可以通过 Packets 方法获取，通过 Inject 方法模拟收到客户端的消息，通过 CloseWithError 方法模拟连接断开
+type Session struct {
+	handler net4go.Handler
+	data    map[string]interface{}
+	packets []net4go.Packet
+	id      int64
+	mu      sync.Mutex
+	closed  bool
+}
+
+func NewSession() *Session {
+	return &Session{}
+}
+
+func (s *Session) Conn() interface{} {
+	return nil
+}
+
+func (s *Session) SetId(id int64) {
+	s.mu.Lock()
+	s.id = id
+	s.mu.Unlock()
+}
+
+func (s *Session) GetId() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.id
+}
+
+func (s *Session) UpdateHandler(handler net4go.Handler) {
+	s.mu.Lock()
+	s.handler = handler
+	s.mu.Unlock()
+}
+
+func (s *Session) Set(key string, value interface{}) {
+	s.mu.Lock()
+	if s.data == nil {
+		s.data = make(map[string]interface{})
+	}
+	s.data[key] = value
+	s.mu.Unlock()
+}
+
+func (s *Session) Get(key string) interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data[key]
+}
+
+func (s *Session) Del(key string) {
+	s.mu.Lock()
+	delete(s.data, key)
+	s.mu.Unlock()
+}
+
+func (s *Session) Closed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+func (s *Session) AsyncWritePacket(p net4go.Packet) error {
+	return s.WritePacket(p)
+}
+
+func (s *Session) WritePacket(p net4go.Packet) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return net4go.ErrSessionClosed
+	}
+	s.packets = append(s.packets, p)
+	return nil
+}
+
+// Packets 获取已写入的消息
+func (s *Session) Packets() []net4go.Packet {
+	s.mu.Lock()
+	var packets = make([]net4go.Packet, len(s.packets))
+	copy(packets, s.packets)
+	s.mu.Unlock()
+	return packets
+}
+
+// Inject 模拟收到客户端的消息，消息会交由当前的 Handler 处理，连接已关闭或者没有 Handler 的时候返回 false
+func (s *Session) Inject(p net4go.Packet) bool {
+	s.mu.Lock()
+	var handler = s.handler
+	var closed = s.closed
+	s.mu.Unlock()
+
+	if closed || handler == nil {
+		return false
+	}
+	handler.OnMessage(s, p)
+	return true
+}
+
+// CloseWithError 模拟连接异常断开，Handler 的 OnClose 方法会收到 err
+func (s *Session) CloseWithError(err error) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	var handler = s.handler
+	s.handler = nil
+	s.closed = true
+	s.mu.Unlock()
+
+	if handler != nil {
+		handler.OnClose(s, err)
+	}
+	return nil
+}
+
+func (s *Session) Close() error {
+	return s.CloseWithError(nil)
+}
@@ -2,6 +2,7 @@ package newbee
 
 import (
 	"github.com/smartwalle/net4go"
+	"sync"
 )
 
 type Player interface {
@@ -25,9 +26,17 @@ type Player interface {
 	Close() error
 }
 
+// SessionUpdater 玩家可以选择实现本接口，用于支持断线重连，NewPlayer 创建的玩家已经实现了本接口
+// 玩家没有实现本接口的时候，Room 的 ReconnectPlayer 方法会返回 ErrNotSessionUpdater
+type SessionUpdater interface {
+	// UpdateSession 更新玩家的连接信息，断线重连的时候 Room 会调用此方法绑定新的连接
+	UpdateSession(sess net4go.Session)
+}
+
 type player struct {
 	sess net4go.Session
 	id   int64
+	mu   sync.RWMutex
 }
 
 func NewPlayer(id int64, sess net4go.Session) Player {
@@ -42,35 +51,50 @@ func (p *player) GetId() int64 {
 }
 
 func (p *player) Session() net4go.Session {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 	return p.sess
 }
 
+func (p *player) UpdateSession(sess net4go.Session) {
+	p.mu.Lock()
+	p.sess = sess
+	p.mu.Unlock()
+}
+
 func (p *player) Connected() bool {
-	return p.sess != nil && p.sess.Closed() == false
+	var sess = p.Session()
+	return sess != nil && sess.Closed() == false
 }
 
 func (p *player) SendPacket(packet net4go.Packet) {
-	if p.sess == nil {
+	var sess = p.Session()
+	if sess == nil {
 		return
 	}
-	if err := p.sess.WritePacket(packet); err != nil {
+	if err := sess.WritePacket(packet); err != nil {
 		p.Close()
 	}
 }
 
 func (p *player) AsyncSendPacket(packet net4go.Packet) {
-	if p.sess == nil {
+	var sess = p.Session()
+	if sess == nil {
 		return
 	}
-	if err := p.sess.AsyncWritePacket(packet); err != nil {
+	if err := sess.AsyncWritePacket(packet); err != nil {
 		p.Close()
 	}
 }
 
 func (p *player) Close() error {
-	if p.sess != nil {
-		p.sess.Close()
-	}
+	p.mu.Lock()
+	var sess = p.sess
 	p.sess = nil
+	p.mu.Unlock()
+
+	if sess != nil {
+		sess.Close()
+	}
 	return nil
 }
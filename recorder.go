@@ -0,0 +1,140 @@
+package newbee
+
+import (
+	"sync"
+	"time"
+)
+
+type RecordType int
+
+const (
+	RecordTypeMessage          RecordType = 1  // 玩家消息
+	RecordTypeCustom           RecordType = 2  // 自定义消息
+	RecordTypePlayerIn         RecordType = 3  // 玩家加入房间，Data 为玩家是否为机器人
+	RecordTypePlayerOut        RecordType = 4  // 玩家离开房间
+	RecordTypePlayerDisconnect RecordType = 5  // 玩家断线
+	RecordTypePlayerReconnect  RecordType = 6  // 玩家重连
+	RecordTypeReconnectTimeout RecordType = 7  // 玩家断线重连超时
+	RecordTypeObserverMessage  RecordType = 8  // 观察者消息
+	RecordTypeTick             RecordType = 9  // 定时器，Data 为 TickInfo
+	RecordTypeFrame            RecordType = 10 // 帧同步模式下的一帧，Data 为本帧收集到的玩家输入
+	RecordTypeCall             RecordType = 11 // 通过 Room 的 Call 方法发送的请求，Data 为请求内容
+	RecordTypePlayerIdle       RecordType = 12 // 玩家空闲超时（IdleActionNotify），Data 为玩家的空闲时间
+	RecordTypeTimer            RecordType = 13 // Room 的 AfterFunc 和 Every 创建的定时器触发，Data 为定时器的序号
+	RecordTypePause            RecordType = 14 // 房间暂停
+	RecordTypeResume           RecordType = 15 // 房间恢复，暂停期间缓存的玩家消息会在本记录之后作为 RecordTypeMessage 记录
+)
+
+// Record 房间处理的一条消息
+type Record struct {
+	Time     time.Time   // 记录时间
+	Data     interface{} // 消息内容，玩家消息为 net4go.Packet，如果 Game 会修改消息内容，Recorder 应该在 Record 方法中完成序列化
+	Error    error       // 玩家离开房间或者断线的原因
+	Frame    uint64      // 帧 id，只在 WithFrame 和 WithLockstep 模式下有效
+	PlayerId int64       // 玩家（或者观察者） id
+	Type     RecordType  // 消息类型
+}
+
+// Recorder 用于记录房间处理的消息，通过 WithRecorder 设置
+// 除了 WithAsync 模式下的 RecordTypeTick 之外，Record 方法都在 Room 的消息处理协程中调用
+type Recorder interface {
+	Record(record Record)
+}
+
+// MemoryRecorder 将消息记录在内存中
+type MemoryRecorder struct {
+	records []Record
+	mu      sync.Mutex
+}
+
+func NewMemoryRecorder() *MemoryRecorder {
+	return &MemoryRecorder{}
+}
+
+func (r *MemoryRecorder) Record(record Record) {
+	r.mu.Lock()
+	r.records = append(r.records, record)
+	r.mu.Unlock()
+}
+
+// Records 获取已记录的消息
+func (r *MemoryRecorder) Records() []Record {
+	r.mu.Lock()
+	var records = make([]Record, len(r.records))
+	copy(records, r.records)
+	r.mu.Unlock()
+	return records
+}
+
+func recordType(mType messageType) RecordType {
+	switch mType {
+	case mTypeDefault:
+		return RecordTypeMessage
+	case mTypeCustom:
+		return RecordTypeCustom
+	case mTypePlayerIn:
+		return RecordTypePlayerIn
+	case mTypePlayerOut:
+		return RecordTypePlayerOut
+	case mTypePlayerDisconnect:
+		return RecordTypePlayerDisconnect
+	case mTypePlayerReconnect:
+		return RecordTypePlayerReconnect
+	case mTypeReconnectTimeout:
+		return RecordTypeReconnectTimeout
+	case mTypeObserverMessage:
+		return RecordTypeObserverMessage
+	case mTypeTick:
+		return RecordTypeTick
+	case mTypeCall:
+		return RecordTypeCall
+	case mTypeTimer:
+		return RecordTypeTimer
+	case mTypePause:
+		return RecordTypePause
+	case mTypeResume:
+		return RecordTypeResume
+	}
+	return 0
+}
+
+func (r *room) record(rType RecordType, playerId int64, data interface{}, err error) {
+	if r.recorder == nil || rType == 0 {
+		return
+	}
+	r.recorder.Record(Record{
+		Time:     r.clock.Now(),
+		Data:     data,
+		Error:    err,
+		Frame:    r.frameId,
+		PlayerId: playerId,
+		Type:     rType,
+	})
+}
+
+func (r *room) recordMessage(m *message) {
+	if r.recorder == nil {
+		return
+	}
+
+	switch m.Type {
+	case mTypeDefault:
+		// WithLockstep 模式下玩家消息会作为输入记录在 RecordTypeFrame 中
+		if r.collect == nil {
+			r.record(recordType(m.Type), m.PlayerId, m.Data, m.Error)
+		}
+	case mTypeCustom, mTypeObserverMessage:
+		r.record(recordType(m.Type), m.PlayerId, m.Data, m.Error)
+	case mTypeCall:
+		r.record(recordType(m.Type), m.PlayerId, m.Data.(*call).request, m.Error)
+	case mTypeTimer:
+		// 记录定时器的序号，回放时 Game 会按照相同的顺序创建定时器，据此找到对应的定时器
+		r.record(recordType(m.Type), m.PlayerId, m.Data.(*roomTimer).seq, m.Error)
+	case mTypePlayerIn:
+		// 记录玩家是否为机器人，回放时据此创建相应的玩家
+		r.record(recordType(m.Type), m.PlayerId, IsBot(m.Player), m.Error)
+	default:
+		// 其它消息的 Data 为连接等运行时信息，回放时会重新构建，不需要记录
+		r.record(recordType(m.Type), m.PlayerId, nil, m.Error)
+	}
+}
@@ -0,0 +1,134 @@
+package newbee
+
+import (
+	"runtime/debug"
+	"time"
+)
+
+// Replay 将 Recorder 记录的消息依次交由 game 处理，用于复现对局
+// 回放不需要真实的网络连接，Room 会为每一个玩家创建一个虚拟的连接，向玩家发送的消息都会被丢弃
+// 消息会经过和正常运行时相同的处理流程，Game 的 OnJoinRoom、OnMessage、OnLeaveRoom、OnTick 等方法会按照记录的顺序被调用
+// TickerWithInfo 的 OnTickInfo 方法会收到和记录时相同的 TickInfo，PauseHandler 的 OnPause 和 OnResume 方法会按照记录的顺序被调用
+// Room 的 AfterFunc 和 Every 创建的定时器不会自行触发，而是按照记录的顺序触发，Game 需要按照和记录时相同的顺序创建定时器
+// opts 用于设置会影响消息处理结果的选项，例如 WithMaxPlayers，应该和记录时使用的选项保持一致
+func Replay(roomId int64, game Game, records []Record, opts ...RoomOption) (err error) {
+	if game == nil {
+		return ErrNilGame
+	}
+
+	var r = NewRoom(roomId, opts...).(*room)
+
+	// 回放过程中不处理队列中的消息，断线重连和 AfterFunc 等定时器产生的消息会被直接丢弃
+	r.queue.Close()
+
+	r.mu.Lock()
+	r.state = RoomStateRunning
+	r.closed = make(chan struct{}, 1)
+	r.gameState = game.GetState()
+	r.mu.Unlock()
+
+	game.OnRunInRoom(r)
+
+	defer func() {
+		game.OnCloseRoom(r)
+		r.clean()
+	}()
+
+	defer func() {
+		if v := recover(); v != nil {
+			err = newStackError(v, debug.Stack())
+
+			r.panic(game, err)
+		}
+	}()
+
+	var rErr = make(chan error, 1)
+	var m = &message{}
+
+	for _, record := range records {
+		r.frameId = record.Frame
+
+		*m = message{PlayerId: record.PlayerId, Error: record.Error}
+
+		switch record.Type {
+		case RecordTypeTick:
+			var info, _ = record.Data.(TickInfo)
+			tick(game, info)
+			continue
+		case RecordTypeFrame:
+			if h, ok := game.(FrameHandler); ok {
+				h.OnFrame(record.Frame, record.Data.(map[int64][]interface{}))
+			}
+			continue
+		case RecordTypePlayerIdle:
+			var p = r.GetPlayer(record.PlayerId)
+			if h, ok := game.(IdleHandler); ok && p != nil {
+				var idle, _ = record.Data.(time.Duration)
+				h.OnPlayerIdle(p, idle)
+			}
+			continue
+		case RecordTypeMessage:
+			m.Type = mTypeDefault
+			m.Data = record.Data
+		case RecordTypeCustom:
+			m.Type = mTypeCustom
+			m.Data = record.Data
+		case RecordTypeObserverMessage:
+			m.Type = mTypeObserverMessage
+			m.Data = record.Data
+		case RecordTypeCall:
+			m.Type = mTypeCall
+			m.Data = &call{request: record.Data}
+			m.rError = rErr
+		case RecordTypePlayerIn:
+			m.Type = mTypePlayerIn
+			if isBot, _ := record.Data.(bool); isBot {
+				m.Player = NewBot(record.PlayerId, nil)
+			} else {
+				m.Player = NewPlayer(record.PlayerId, newBotSession(nil))
+			}
+			m.rError = rErr
+		case RecordTypePlayerOut:
+			m.Type = mTypePlayerOut
+		case RecordTypePlayerDisconnect:
+			var p = r.GetPlayer(record.PlayerId)
+			if p == nil || p.Session() == nil {
+				continue
+			}
+			m.Type = mTypePlayerDisconnect
+			m.Data = p.Session()
+		case RecordTypePlayerReconnect:
+			m.Type = mTypePlayerReconnect
+			m.Data = newBotSession(nil)
+			m.rError = rErr
+		case RecordTypeTimer:
+			var seq, _ = record.Data.(uint64)
+			var t = r.findTimer(seq)
+			if t == nil {
+				continue
+			}
+			m.Type = mTypeTimer
+			m.Data = t
+		case RecordTypePause:
+			m.Type = mTypePause
+		case RecordTypeResume:
+			m.Type = mTypeResume
+		case RecordTypeReconnectTimeout:
+			var d = r.disconnected[record.PlayerId]
+			if d == nil {
+				continue
+			}
+			m.Type = mTypeReconnectTimeout
+			m.Data = d
+		default:
+			continue
+		}
+
+		r.dispatch(game, m)
+
+		if m.rError != nil {
+			<-rErr
+		}
+	}
+	return nil
+}
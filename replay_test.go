@@ -0,0 +1,122 @@
+package newbee_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/smartwalle/newbee"
+	"github.com/smartwalle/newbee/newbeetest"
+)
+
+func TestReplay(t *testing.T) {
+	var recorder = newbee.NewMemoryRecorder()
+	var game = newbeetest.NewGame(1)
+	game.Interval = 0
+	var room = newbeetest.RunRoom(t, game, newbee.WithSync(), newbee.WithRecorder(recorder))
+	var sessions = newbeetest.JoinPlayers(t, room, 2)
+
+	sessions[0].Inject(newPacket("a"))
+	sessions[1].Inject(newPacket("b"))
+	room.Enqueue("custom")
+	room.RemovePlayer(1)
+	sessions[1].Close()
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnJoinRoom", "OnMessage", "OnMessage", "OnDequeue", "OnLeaveRoom", "OnLeaveRoom")
+
+	var replayed = newbeetest.NewGame(1)
+	if err := newbee.Replay(1, replayed, recorder.Records()); err != nil {
+		t.Fatal(err)
+	}
+
+	var want = append(game.Calls(), newbeetest.Call{Name: "OnCloseRoom"})
+	var got = replayed.Calls()
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", replayed.Names(), append(game.Names(), "OnCloseRoom"))
+	}
+	for i := range want {
+		if got[i].Name != want[i].Name || got[i].PlayerId != want[i].PlayerId || packetData(got[i].Message) != packetData(want[i].Message) {
+			t.Fatalf("call %d: got %+v, want %+v", i, got[i], want[i])
+		}
+		if newbee.GetLeaveReason(got[i].Error) != newbee.GetLeaveReason(want[i].Error) {
+			t.Fatalf("call %d: got %v, want %v", i, got[i].Error, want[i].Error)
+		}
+	}
+}
+
+// timerGame 玩家加入房间之后创建定时器，定时器触发的时候记录一次 OnDequeue
+type timerGame struct {
+	*newbeetest.Game
+}
+
+func (g timerGame) OnJoinRoom(player newbee.Player) {
+	g.Game.OnJoinRoom(player)
+	g.Room().Every(time.Millisecond*10, func() { g.Game.OnDequeue("every") })
+	g.Room().AfterFunc(time.Millisecond*15, func() { g.Game.OnDequeue("after") })
+}
+
+func TestReplayTimer(t *testing.T) {
+	var clock = newbee.NewFakeClock(time.Now())
+	var recorder = newbee.NewMemoryRecorder()
+	var game = timerGame{newbeetest.NewGame(1)}
+	game.Interval = 0
+	var room = newbeetest.RunRoom(t, game, newbee.WithSync(), newbee.WithClock(clock), newbee.WithRecorder(recorder))
+	newbeetest.JoinPlayers(t, room, 1)
+
+	clock.Advance(time.Millisecond * 10)
+	newbeetest.ExpectCalls(t, game.Game, "OnRunInRoom", "OnJoinRoom", "OnDequeue")
+	clock.BlockUntil(2)
+	clock.Advance(time.Millisecond * 10)
+	newbeetest.ExpectCalls(t, game.Game, "OnRunInRoom", "OnJoinRoom", "OnDequeue", "OnDequeue", "OnDequeue")
+	clock.BlockUntil(1)
+	clock.Advance(time.Millisecond * 10)
+	newbeetest.ExpectCalls(t, game.Game, "OnRunInRoom", "OnJoinRoom", "OnDequeue", "OnDequeue", "OnDequeue", "OnDequeue")
+	room.Close()
+	newbeetest.ExpectCalls(t, game.Game, "OnRunInRoom", "OnJoinRoom", "OnDequeue", "OnDequeue", "OnDequeue", "OnDequeue", "OnLeaveRoom", "OnCloseRoom")
+
+	// 回放的时候定时器按照记录的顺序触发
+	var replayed = timerGame{newbeetest.NewGame(1)}
+	if err := newbee.Replay(1, replayed, recorder.Records()); err != nil {
+		t.Fatal(err)
+	}
+
+	var messages = func(game *newbeetest.Game) []interface{} {
+		var messages []interface{}
+		for _, call := range game.Calls() {
+			if call.Name == "OnDequeue" {
+				messages = append(messages, call.Message)
+			}
+		}
+		return messages
+	}
+	var want = []interface{}{"every", "after", "every", "every"}
+	if got := messages(game.Game); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if got := messages(replayed.Game); !reflect.DeepEqual(got, want) {
+		t.Fatalf("replay got %v, want %v", got, want)
+	}
+}
+
+func TestReplayPause(t *testing.T) {
+	var recorder = newbee.NewMemoryRecorder()
+	var game = newbeetest.NewGame(1)
+	game.Interval = 0
+	var room = newbeetest.RunRoom(t, game, newbee.WithSync(), newbee.WithRecorder(recorder))
+	var sessions = newbeetest.JoinPlayers(t, room, 1)
+
+	room.Pause()
+	sessions[0].Inject(newPacket("a"))
+	room.Enqueue("custom")
+	room.Resume()
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnPause", "OnDequeue", "OnResume", "OnMessage")
+
+	// 回放的时候 OnPause 和 OnResume 按照记录的顺序被调用，暂停期间缓存的消息在 OnResume 之后处理
+	var replayed = newbeetest.NewGame(1)
+	if err := newbee.Replay(1, replayed, recorder.Records()); err != nil {
+		t.Fatal(err)
+	}
+	var want = append(game.Names(), "OnCloseRoom")
+	if got := replayed.Names(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
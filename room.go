@@ -1,23 +1,37 @@
 package newbee
 
 import (
+	"context"
 	"errors"
 	"github.com/smartwalle/net4go"
 	"sync"
+	"time"
 )
 
 var (
-	ErrRoomClosed     = errors.New("newbee: room is closed")
-	ErrRoomRunning    = errors.New("newbee: room is running")
-	ErrRoomNotRunning = errors.New("newbee: room is not running")
-	ErrNilGame        = errors.New("newbee: game is nil")
-	ErrPlayerExists   = errors.New("newbee: player already exists")
-	ErrPlayerNotExist = errors.New("newbee: player not exist")
-	ErrInvalidPlayer  = errors.New("newbee: invalid player")
-	ErrNilPlayer      = errors.New("newbee: player is nil")
-	ErrFailedToRun    = errors.New("newbee: failed to run the room")
-	ErrBadSession     = errors.New("newbee: bad session")
-	ErrBadInterval    = errors.New("newbee: bad interval")
+	ErrRoomClosed        = errors.New("newbee: room is closed")
+	ErrRoomRunning       = errors.New("newbee: room is running")
+	ErrRoomNotRunning    = errors.New("newbee: room is not running")
+	ErrNilGame           = errors.New("newbee: game is nil")
+	ErrPlayerExists      = errors.New("newbee: player already exists")
+	ErrPlayerNotExist    = errors.New("newbee: player not exist")
+	ErrInvalidPlayer     = errors.New("newbee: invalid player")
+	ErrNilPlayer         = errors.New("newbee: player is nil")
+	ErrBadSession        = errors.New("newbee: bad session")
+	ErrBadInterval       = errors.New("newbee: bad interval")
+	ErrRoomExists        = errors.New("newbee: room already exists")
+	ErrNotFrameHandler   = errors.New("newbee: game does not implement FrameHandler")
+	ErrNotCallHandler    = errors.New("newbee: game does not implement CallHandler")
+	ErrManagerClosed     = errors.New("newbee: room manager is closed")
+	ErrBadGameState      = errors.New("newbee: illegal game state transition")
+	ErrRoomFull          = errors.New("newbee: room is full")
+	ErrBadToken          = errors.New("newbee: bad token")
+	ErrTokenExpired      = errors.New("newbee: token expired")
+	ErrPlayerBanned      = errors.New("newbee: player is banned")
+	ErrNotSessionUpdater = errors.New("newbee: player does not implement SessionUpdater")
+
+	// Deprecated: Room 启动失败的时候会直接返回具体的错误（例如 ErrBadInterval、ErrNotFrameHandler），不再返回此错误
+	ErrFailedToRun = errors.New("newbee: failed to run the room")
 )
 
 type RoomState uint32
@@ -26,6 +40,7 @@ const (
 	RoomStateClose   RoomState = iota // 房间已关闭
 	RoomStatePending                  // 等待游戏运行
 	RoomStateRunning                  // 有游戏在运行(和游戏的状态无关，调用 Room 的 Run() 方法成功之后，就会将 Room 的状态调整为此状态)
+	RoomStatePaused                   // 游戏已暂停，调用 Room 的 Resume() 方法之后恢复为 RoomStateRunning
 )
 
 //type roomOptions struct {
@@ -53,6 +68,130 @@ func WithWaiter(w Waiter) RoomOption {
 	}
 }
 
+// WithReconnectTimeout 设置断线重连的等待时间
+// 玩家的网络连接断开之后，不会立即将玩家移出房间，而是将其标记为断线状态，并触发 ReconnectHandler 的 OnPlayerDisconnected 方法
+// 如果玩家在等待时间内通过 Room 的 ReconnectPlayer 方法绑定了新的连接，则会触发 ReconnectHandler 的 OnPlayerReconnected 方法
+// 超过等待时间之后，才会将玩家移出房间并触发 Game 的 OnLeaveRoom 方法
+func WithReconnectTimeout(d time.Duration) RoomOption {
+	return func(r *room) {
+		r.reconnectTimeout = d
+	}
+}
+
+// WithObserverDelay 设置观察者消息的延迟时间
+// 通过 Room 的 BroadcastPacketWithObservers 方法广播消息时，观察者会延迟 d 收到消息，用于防止观察者向玩家泄露实时信息
+func WithObserverDelay(d time.Duration) RoomOption {
+	return func(r *room) {
+		r.observerDelay = d
+	}
+}
+
+// WithRecorder 设置消息记录器，Room 处理的所有消息都会交由 Recorder 记录，记录的消息可以通过 Replay 函数进行回放
+func WithRecorder(recorder Recorder) RoomOption {
+	return func(r *room) {
+		r.recorder = recorder
+	}
+}
+
+// WithQueueCapacity 限制队列中网络消息（玩家消息和观察者消息）的数量，队列已满时按照 policy 处理新收到的消息
+// 玩家加入、离开以及定时器等消息不受此限制，被丢弃的消息数量可以通过 Room 的 GetDroppedCount 方法获取
+// 房间暂停期间缓存的玩家消息（PausePolicyBuffer）以及 WithLockstep 模式下每一帧收集的玩家输入同样受此限制
+// QueuePolicyBlock 会阻塞发送消息的协程，使用此策略的时候，不能在 Room 的消息处理协程中（Game 的回调方法中）模拟玩家发送消息，机器人除外
+func WithQueueCapacity(n int, policy QueuePolicy) RoomOption {
+	return func(r *room) {
+		r.queueCapacity = n
+		r.queuePolicy = policy
+	}
+}
+
+// WithRateLimit 限制每个玩家发送消息的频率，每个玩家每秒最多发送 rate 条消息，允许突发 burst 条消息
+// 超出限制的消息将被丢弃，并按照 policy 进行处理，被丢弃的消息数量可以通过 Room 的 GetRateLimitedCount 方法获取
+// rate 或者 burst 小于等于 0 的时候不限制玩家发送消息的频率
+func WithRateLimit(rate float64, burst int, policy RateLimitPolicy) RoomOption {
+	return func(r *room) {
+		if rate <= 0 || burst <= 0 {
+			r.limiter = nil
+			return
+		}
+		r.limiter = newRateLimiter(rate, burst, policy)
+	}
+}
+
+// WithMessageInterceptor 添加消息拦截器，拦截器会包裹 Game 的 OnMessage、OnDequeue、OnJoinRoom 和 OnLeaveRoom 方法
+// 多个拦截器按照添加的顺序由外向内执行，适用于所有的运行模式，WithLockstep 模式下玩家消息经过拦截器之后才会被收集到当前帧的输入中
+func WithMessageInterceptor(interceptors ...Interceptor) RoomOption {
+	return func(r *room) {
+		for _, interceptor := range interceptors {
+			if interceptor != nil {
+				r.interceptors = append(r.interceptors, interceptor)
+			}
+		}
+	}
+}
+
+// WithClock 设置时钟，Room 中所有和时间相关的操作都通过 clock 完成，测试的时候可以使用 FakeClock
+func WithClock(clock Clock) RoomOption {
+	return func(r *room) {
+		if clock != nil {
+			r.clock = clock
+		}
+	}
+}
+
+// WithMetrics 设置指标收集器，用于统计队列深度、消息数量、OnTick 执行时间、玩家数量等信息
+func WithMetrics(metrics Metrics) RoomOption {
+	return func(r *room) {
+		r.metrics = metrics
+	}
+}
+
+// WithTickPolicy 设置 tick 的调度策略，默认为 TickPolicyDefault
+func WithTickPolicy(policy TickPolicy) RoomOption {
+	return func(r *room) {
+		r.tickPolicy = policy
+	}
+}
+
+// WithPausePolicy 设置房间暂停期间玩家消息的处理策略，默认为 PausePolicyBuffer
+func WithPausePolicy(policy PausePolicy) RoomOption {
+	return func(r *room) {
+		r.pausePolicy = policy
+	}
+}
+
+// WithGameOverPolicy 设置游戏进入 GameStateOver 之后房间的处理策略，默认为 GameOverPolicyClose
+// 游戏进入 GameStateStop 之后房间总是会关闭
+func WithGameOverPolicy(policy GameOverPolicy) RoomOption {
+	return func(r *room) {
+		r.gameOverPolicy = policy
+	}
+}
+
+// WithMaxPlayers 设置房间的最大玩家数量（包含机器人，不包含观察者），房间已满的时候 AddPlayer 会返回 ErrRoomFull
+// n 小于等于 0 的时候不限制玩家数量
+func WithMaxPlayers(n int) RoomOption {
+	return func(r *room) {
+		r.maxPlayers = n
+	}
+}
+
+// WithAuthenticator 设置 Room 的 JoinWithToken 方法使用的 Authenticator，例如 NewTicketAuthenticator
+func WithAuthenticator(authenticator Authenticator) RoomOption {
+	return func(r *room) {
+		r.authenticator = authenticator
+	}
+}
+
+// WithIdleTimeout 设置玩家的空闲超时时间，玩家超过 d 没有发送消息的时候，Room 会根据 action 进行处理
+// 机器人和断线等待重连的玩家不会超时，房间暂停期间也不会检查
+func WithIdleTimeout(d time.Duration, action IdleAction) RoomOption {
+	return func(r *room) {
+		if d > 0 {
+			r.idle = newIdleTracker(d, action)
+		}
+	}
+}
+
 // WithSync 网络消息和定时器消息为同步模式
 // 网络消息和定时器消息会放入同一队列等待执行
 // 定时任务放入队列之后，定时器就会暂停，需要等到队列中的定时任务执行之后才会再次激活定时器
@@ -82,6 +221,17 @@ func WithFrame() RoomOption {
 	}
 }
 
+// WithLockstep 帧同步模式，在 WithFrame 的基础上为每一帧分配一个单调递增的帧 id
+// 每一帧内收到的网络消息不会交由 Game 的 OnMessage 处理，而是按玩家分组收集起来，然后调用 FrameHandler 的 OnFrame 方法
+// OnFrame 方法的返回值不为 nil 的时候，会将其广播给所有玩家（以及观察者），客户端根据收到的帧数据进行确定性的模拟
+// 使用此模式时，Game 需要实现 FrameHandler 接口，否则 Room 的 Run 方法会返回 ErrNotFrameHandler
+func WithLockstep() RoomOption {
+	return func(r *room) {
+		r.queue = newQueue()
+		r.mode = newLockstepRoom(r)
+	}
+}
+
 type Room interface {
 	// GetId 获取房间 id
 	GetId() int64
@@ -105,44 +255,171 @@ type Room interface {
 	GetPlayerCount() int
 
 	// AddPlayer 添加玩家，如果玩家已经存在或者 player 参数为空，会返回相应的错误，如果连接不为空，则将该玩家和连接进行绑定
+	// 房间已满（WithMaxPlayers）的时候返回 ErrRoomFull，Game 实现了 JoinRequestHandler 的时候，会返回 OnJoinRequest 的错误信息
 	AddPlayer(player Player) error
 
-	// RemovePlayer 移除玩家
+	// JoinWithToken 验证凭证之后添加玩家，凭证由 WithAuthenticator 设置的 Authenticator 验证，没有设置的时候和房间的 token（WithToken）进行比较
+	// 既没有设置 WithAuthenticator 也没有设置 WithToken 的时候，所有凭证（包括空字符串）都验证失败
+	// 验证失败的时候返回 ErrBadToken 或者 ErrTokenExpired，此时玩家的连接不会和房间进行绑定
+	JoinWithToken(player Player, token string) error
+
+	// RemovePlayer 移除玩家，OnLeaveRoom 收到的离开原因为 LeaveReasonRemoved
 	RemovePlayer(playerId int64)
 
+	// KickPlayer 踢出玩家，packet 不为空的时候，会在关闭玩家的连接之前发送给玩家，OnLeaveRoom 收到的离开原因为 reason
+	KickPlayer(playerId int64, reason LeaveReason, packet net4go.Packet)
+
+	// BanPlayer 封禁玩家，玩家在房间中的时候会被踢出（LeaveReasonBan），被封禁的玩家不能通过 AddPlayer 加入房间，AddPlayer 会返回 ErrPlayerBanned
+	BanPlayer(playerId int64, packet net4go.Packet)
+
+	// UnbanPlayer 解除封禁
+	UnbanPlayer(playerId int64)
+
+	// IsBanned 玩家是否被封禁
+	IsBanned(playerId int64) bool
+
+	// ReconnectPlayer 为断线（或者连接异常）的玩家绑定新的连接，玩家不存在的时候会返回 ErrPlayerNotExist
+	// 玩家需要实现 SessionUpdater 接口，否则返回 ErrNotSessionUpdater
+	ReconnectPlayer(playerId int64, sess net4go.Session) error
+
+	// GetObserver 获取观察者信息
+	GetObserver(observerId int64) Player
+
+	// RangeObserver 只读遍历观察者信息，在回调函数中，不可执行 Room 的其它可以影响观察者列表的操作
+	RangeObserver(fn func(observer Player))
+
+	// GetObserverCount 获取观察者数量，观察者不计入玩家数量
+	GetObserverCount() int
+
+	// AddObserver 添加观察者，观察者发送的消息将交由 ObserverHandler 处理
+	AddObserver(observer Player) error
+
+	// RemoveObserver 移除观察者，并关闭观察者的连接
+	RemoveObserver(observerId int64)
+
 	// Run 启动
 	Run(game Game) error
 
+	// RunContext 启动，ctx 结束的时候会关闭房间
+	RunContext(ctx context.Context, game Game) error
+
 	// Enqueue 添加自定义消息
 	Enqueue(message interface{})
 
+	// AfterFunc 在 d 之后执行 fn，fn 会通过队列在 Room 的消息处理协程中执行，Room 关闭的时候会自动停止
+	AfterFunc(d time.Duration, fn func()) Timer
+
+	// Every 每隔 d 执行一次 fn，fn 会通过队列在 Room 的消息处理协程中执行，Room 关闭的时候会自动停止
+	Every(d time.Duration, fn func()) Timer
+
+	// SetTickInterval 修改刷新时间间隔，d 小于等于 0 的时候暂停 tick，可以在 Game 的回调方法中调用
+	// 房间运行的时候会使用 Game 的 TickInterval 作为初始值，房间没有运行的时候返回 ErrRoomNotRunning
+	// WithFrame 和 WithLockstep 模式下暂停 tick 之后，仍然会按照之前的时间间隔处理队列中的消息，但是不会触发 OnTick 和 OnFrame
+	SetTickInterval(d time.Duration) error
+
+	// GetTickInterval 获取当前的刷新时间间隔
+	GetTickInterval() time.Duration
+
+	// Pause 暂停房间，暂停期间不会触发 OnTick 和 OnFrame，玩家消息会根据 WithPausePolicy 设置的策略进行缓存或者丢弃
+	// 玩家加入、离开、断线重连以及自定义消息等仍然会正常处理，Game 可以实现 PauseHandler 接收通知
+	Pause() error
+
+	// Resume 恢复暂停的房间
+	Resume() error
+
+	// Call 发送请求并等待 Game 处理的结果，请求由 CallHandler 的 OnCall 方法处理
+	// Room 关闭的时候返回 ErrRoomClosed，ctx 结束的时候返回 ctx.Err()，Game 没有实现 CallHandler 的时候返回 ErrNotCallHandler
+	Call(ctx context.Context, request interface{}) (interface{}, error)
+
 	// SendPacket 向指定玩家发送消息
 	SendPacket(playerId int64, packet net4go.Packet)
 
+	// GetDroppedCount 获取因为队列已满（WithQueueCapacity）而被丢弃的消息数量
+	GetDroppedCount() uint64
+
+	// GetRateLimitedCount 获取玩家因为超出频率限制（WithRateLimit）而被丢弃的消息数量，玩家离开房间之后依然可以获取
+	GetRateLimitedCount(playerId int64) uint64
+
 	// BroadcastPacket 向所有玩家广播消息
 	BroadcastPacket(packet net4go.Packet)
 
+	// BroadcastPacketWithObservers 向所有玩家和观察者广播消息，如果设置了 WithObserverDelay，观察者将延迟收到消息
+	BroadcastPacketWithObservers(packet net4go.Packet)
+
 	// Close 关闭房间
 	Close() error
+
+	// Shutdown 关闭房间，并等待房间处理完所有玩家的 OnLeaveRoom 和 Game 的 OnCloseRoom 之后返回
+	// 如果 ctx 先结束，则返回 ctx.Err()
+	Shutdown(ctx context.Context) error
 }
 
 type roomMode interface {
+	// Check 在房间进入 RoomStateRunning 状态之前调用，检查 game 是否满足当前运行模式的要求
+	Check(game Game) error
+
 	Run(game Game) error
 
+	// OnTickInterval 刷新时间间隔被修改之后调用，可能在任意协程中调用
+	OnTickInterval()
+
 	OnClose() error
 }
 
+type call struct {
+	request  interface{}
+	response interface{}
+	err      error
+}
+
+type disconnection struct {
+	timer ClockTimer
+	err   error
+}
+
 type room struct {
-	queue       iMessageQueue
-	waiter      Waiter
-	mode        roomMode
-	messagePool *sync.Pool
-	players     map[int64]Player
-	token       string
-	id          int64
-	mu          sync.RWMutex
-	state       RoomState
-	closed      chan struct{}
+	queue            iMessageQueue
+	waiter           Waiter
+	mode             roomMode
+	messagePool      *sync.Pool
+	players          map[int64]Player
+	observers        map[int64]Player
+	observerHandler  *observerHandler
+	recorder         Recorder
+	bQueue           *boundedMessageQueue
+	limiter          *rateLimiter
+	interceptors     []Interceptor
+	timers           map[*roomTimer]struct{}
+	timerSeq         uint64
+	clock            Clock
+	metrics          Metrics
+	ticker           *tickScheduler
+	tickPolicy       TickPolicy
+	tickInterval     time.Duration
+	pausePolicy      PausePolicy
+	gameOverPolicy   GameOverPolicy
+	maxPlayers       int
+	authenticator    Authenticator
+	banned           map[int64]struct{}
+	idle             *idleTracker
+	gameState        GameState
+	gameStateMu      sync.Mutex
+	pauseBuffer      []bufferedMessage
+	paused           bool
+	handler          Handler
+	collect          func(player Player, message interface{})
+	disconnected     map[int64]*disconnection
+	token            string
+	id               int64
+	frameId          uint64
+	queueCapacity    int
+	queuePolicy      QueuePolicy
+	reconnectTimeout time.Duration
+	observerDelay    time.Duration
+	mu               sync.RWMutex
+	state            RoomState
+	closed           chan struct{}
+	done             chan struct{}
 }
 
 func NewRoom(id int64, opts ...RoomOption) Room {
@@ -150,6 +427,11 @@ func NewRoom(id int64, opts ...RoomOption) Room {
 	r.id = id
 	r.state = RoomStatePending
 	r.players = make(map[int64]Player)
+	r.observers = make(map[int64]Player)
+	r.observerHandler = &observerHandler{room: r}
+	r.disconnected = make(map[int64]*disconnection)
+	r.timers = make(map[*roomTimer]struct{})
+	r.clock = realClock{}
 	r.messagePool = &sync.Pool{
 		New: func() interface{} {
 			return &message{}
@@ -171,35 +453,60 @@ func NewRoom(id int64, opts ...RoomOption) Room {
 		r.mode = newAsyncRoom(r)
 	}
 
+	r.handler = r.handle
+	for i := len(r.interceptors) - 1; i >= 0; i-- {
+		r.handler = r.interceptors[i](r.handler)
+	}
+
+	if r.queueCapacity > 0 {
+		r.bQueue = newBoundedQueue(r.queue, r.queueCapacity, r.queuePolicy, r.releaseMessage)
+		r.queue = r.bQueue
+	}
+
 	return r
 }
 
+// newMessage 从消息池中获取消息，房间结束之后返回 nil
+// 本方法可能在定时器等其它协程中调用，需要持有 r.mu 读取消息池，所以调用之前不能持有 r.mu
 func (r *room) newMessage(playerId int64, mType messageType, data interface{}, err error) *message {
-	if r.messagePool == nil {
+	var pool = r.pool()
+	if pool == nil {
 		return nil
 	}
-	var m = r.messagePool.Get().(*message)
+	var m = pool.Get().(*message)
 	m.Type = mType
 	m.PlayerId = playerId
 	m.Player = nil
 	m.Data = data
 	m.Error = err
 	m.rError = nil
+	m.dropped = false
 	return m
 }
 
 func (r *room) releaseMessage(m *message) {
-	if m != nil && r.messagePool != nil {
+	if m == nil {
+		return
+	}
+	if pool := r.pool(); pool != nil {
 		m.Type = 0
 		m.PlayerId = 0
 		m.Player = nil
 		m.Data = nil
 		m.Error = nil
 		m.rError = nil
-		r.messagePool.Put(m)
+		pool.Put(m)
 	}
 }
 
+// pool 获取消息池，clean 会在持有 r.mu 的时候将消息池设置为 nil
+func (r *room) pool() *sync.Pool {
+	r.mu.RLock()
+	var pool = r.messagePool
+	r.mu.RUnlock()
+	return pool
+}
+
 func (r *room) GetId() int64 {
 	return r.id
 }
@@ -280,11 +587,21 @@ func (r *room) AddPlayer(player Player) error {
 	}
 
 	r.mu.Lock()
-	if r.state != RoomStateRunning {
+	if !r.active() {
 		r.mu.Unlock()
 		return ErrRoomNotRunning
 	}
 
+	if _, ok := r.banned[player.GetId()]; ok {
+		r.mu.Unlock()
+		return ErrPlayerBanned
+	}
+
+	if r.full() {
+		r.mu.Unlock()
+		return ErrRoomFull
+	}
+
 	r.mu.Unlock()
 
 	return r.enqueuePlayerIn(player)
@@ -306,11 +623,43 @@ func (r *room) AddPlayer(player Player) error {
 	//return nil
 }
 
+// full 房间是否已满，调用之前需要持有 r.mu
+func (r *room) full() bool {
+	return r.maxPlayers > 0 && len(r.players) >= r.maxPlayers
+}
+
 func (r *room) RemovePlayer(playerId int64) {
-	r.enqueuePlayerOut(playerId, nil)
+	r.enqueuePlayerOut(playerId, nil, newLeaveError(LeaveReasonRemoved, nil))
+}
+
+func (r *room) ReconnectPlayer(playerId int64, sess net4go.Session) error {
+	if playerId == 0 {
+		return ErrInvalidPlayer
+	}
+
+	if sess == nil || sess.Closed() {
+		return ErrBadSession
+	}
+
+	r.mu.Lock()
+	if !r.active() {
+		r.mu.Unlock()
+		return ErrRoomNotRunning
+	}
+	r.mu.Unlock()
+
+	return r.enqueuePlayerReconnect(playerId, sess)
 }
 
-func (r *room) Run(game Game) (err error) {
+func (r *room) Run(game Game) error {
+	if err := r.start(game); err != nil {
+		return err
+	}
+	return r.run(game)
+}
+
+// start 检查 game 是否满足运行条件，并将房间的状态调整为 RoomStateRunning
+func (r *room) start(game Game) error {
 	if game == nil {
 		return ErrNilGame
 	}
@@ -321,34 +670,116 @@ func (r *room) Run(game Game) (err error) {
 		return ErrRoomClosed
 	}
 
-	if r.state == RoomStateRunning {
+	if r.active() {
 		r.mu.Unlock()
 		return ErrRoomRunning
 	}
 
+	if err := r.mode.Check(game); err != nil {
+		r.mu.Unlock()
+		return err
+	}
+
 	r.state = RoomStateRunning
 	r.closed = make(chan struct{}, 1)
+	r.done = make(chan struct{})
+	r.tickInterval = game.TickInterval()
+	r.gameState = game.GetState()
 	r.mu.Unlock()
 
-	game.OnRunInRoom(r)
-
 	r.waiter.Add(1)
+	return nil
+}
+
+// run 运行已经通过 start 启动的房间，直到房间关闭之后返回
+func (r *room) run(game Game) error {
 	defer r.waiter.Done()
+	defer close(r.done)
+
+	r.mu.RLock()
+	var mode = r.mode
+	r.mu.RUnlock()
+
+	game.OnRunInRoom(r)
 
-	return r.mode.Run(game)
+	if r.idle != nil {
+		r.scheduleIdleCheck(r.idle.timeout)
+	}
+
+	return mode.Run(game)
+}
+
+func (r *room) RunContext(ctx context.Context, game Game) error {
+	var stop = make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			r.Close()
+		case <-stop:
+		}
+	}()
+
+	return r.Run(game)
 }
 
 func (r *room) OnMessage(sess net4go.Session, p net4go.Packet) {
+	r.input(sess, p)
+}
+
+// input 处理玩家发送的消息，返回消息是否被放入队列
+func (r *room) input(sess net4go.Session, p net4go.Packet) bool {
 	var playerId = sess.GetId()
 	if playerId == 0 {
 		sess.Close()
-		return
+		return false
+	}
+
+	if r.idle != nil {
+		r.idle.touch(playerId, r.clock.Now())
+	}
+
+	if r.rejectMessage() {
+		return false
+	}
+
+	if !r.allowMessage(sess, playerId, p) {
+		return false
 	}
 
 	var m = r.newMessage(playerId, mTypeDefault, p, nil)
-	if m != nil {
-		r.queue.Enqueue(m)
+	if m == nil {
+		return false
+	}
+	return r.enqueueMessage(sess, m)
+}
+
+// enqueueMessage 将网络消息放入队列，如果队列已满，则根据 WithQueueCapacity 设置的策略进行处理，返回消息是否被放入队列
+func (r *room) enqueueMessage(sess net4go.Session, m *message) bool {
+	var ok bool
+	if _, isBot := sess.(*botSession); isBot && r.bQueue != nil {
+		// 机器人的消息可能是在 Room 的消息处理协程中发送的（例如在 Game 的回调方法中调用 Bot 的 Input 方法），不能阻塞
+		ok = r.bQueue.TryEnqueue(m)
+	} else {
+		ok = r.queue.Enqueue(m)
+	}
+	if ok {
+		return true
 	}
+	r.releaseMessage(m)
+
+	if r.queuePolicy == QueuePolicyKick && r.bQueue != nil && !r.Closed() {
+		sess.Close()
+	}
+	return false
+}
+
+func (r *room) GetDroppedCount() uint64 {
+	if r.bQueue == nil {
+		return 0
+	}
+	return r.bQueue.Dropped()
 }
 
 func (r *room) OnClose(sess net4go.Session, err error) {
@@ -359,7 +790,12 @@ func (r *room) OnClose(sess net4go.Session, err error) {
 
 	sess.UpdateHandler(nil)
 
-	r.enqueuePlayerOut(playerId, err)
+	// 机器人没有网络连接，连接关闭即表示机器人离开房间，不需要等待重连
+	if _, isBot := sess.(*botSession); r.reconnectTimeout > 0 && !isBot {
+		r.enqueuePlayerDisconnect(playerId, sess, err)
+		return
+	}
+	r.enqueuePlayerOut(playerId, nil, newLeaveError(LeaveReasonDisconnect, err))
 }
 
 func (r *room) Enqueue(message interface{}) {
@@ -372,25 +808,72 @@ func (r *room) Enqueue(message interface{}) {
 func (r *room) enqueuePlayerIn(player Player) error {
 	var m = r.newMessage(player.GetId(), mTypePlayerIn, nil, nil)
 	if m != nil {
-		var rErr = make(chan error, 1)
 		m.Player = player
-		m.rError = rErr
-		r.queue.Enqueue(m)
+		return r.enqueueAndWait(context.Background(), m)
+	}
+	return nil
+}
 
-		var err error
-		select {
-		case err = <-rErr:
-		case <-r.closed:
-			err = ErrRoomClosed
-		}
-		close(rErr)
-		return err
+func (r *room) enqueuePlayerReconnect(playerId int64, sess net4go.Session) error {
+	var m = r.newMessage(playerId, mTypePlayerReconnect, sess, nil)
+	if m != nil {
+		return r.enqueueAndWait(context.Background(), m)
 	}
 	return nil
 }
 
-func (r *room) enqueuePlayerOut(playerId int64, err error) {
-	var m = r.newMessage(playerId, mTypePlayerOut, nil, err)
+// enqueueAndWait 将消息放入队列，并等待 Room 处理完成之后通过 rError 返回的结果
+func (r *room) enqueueAndWait(ctx context.Context, m *message) error {
+	// rError 不能关闭，ctx 结束之后 Room 依然可能会向 rError 写入结果
+	var rErr = make(chan error, 1)
+	m.rError = rErr
+	if !r.queue.Enqueue(m) {
+		return ErrRoomClosed
+	}
+
+	var err error
+	select {
+	case err = <-rErr:
+	case <-r.closed:
+		err = ErrRoomClosed
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	return err
+}
+
+// Call 向 Room 发送请求，请求会在 Room 的消息处理协程中交由 CallHandler 的 OnCall 方法处理，并等待处理结果
+// 注意：不能在 Room 的消息处理协程中（Game 的各回调方法中）调用本方法
+func (r *room) Call(ctx context.Context, request interface{}) (interface{}, error) {
+	r.mu.Lock()
+	if !r.active() {
+		r.mu.Unlock()
+		return nil, ErrRoomNotRunning
+	}
+	r.mu.Unlock()
+
+	var c = &call{request: request}
+	var m = r.newMessage(0, mTypeCall, c, nil)
+	if m == nil {
+		return nil, ErrRoomClosed
+	}
+
+	if err := r.enqueueAndWait(ctx, m); err != nil {
+		return nil, err
+	}
+	return c.response, c.err
+}
+
+func (r *room) enqueuePlayerDisconnect(playerId int64, sess net4go.Session, err error) {
+	var m = r.newMessage(playerId, mTypePlayerDisconnect, sess, err)
+	if m != nil {
+		r.queue.Enqueue(m)
+	}
+}
+
+// enqueuePlayerOut packet 不为空的时候，会在关闭玩家的连接之前发送给玩家
+func (r *room) enqueuePlayerOut(playerId int64, packet net4go.Packet, err error) {
+	var m = r.newMessage(playerId, mTypePlayerOut, packet, err)
 	if m != nil {
 		r.queue.Enqueue(m)
 	}
@@ -404,11 +887,23 @@ func (r *room) SendPacket(playerId int64, packet net4go.Packet) {
 }
 
 func (r *room) BroadcastPacket(packet net4go.Packet) {
+	// 发送消息的时候不能持有 r.mu，机器人会在当前协程中处理收到的消息，处理的时候可能会调用 Room 的其它方法
+	for _, p := range r.playerList() {
+		p.SendPacket(packet)
+	}
+}
+
+// playerList 获取玩家列表的快照
+func (r *room) playerList() []Player {
 	r.mu.RLock()
+	var ps = make([]Player, 0, len(r.players))
 	for _, p := range r.players {
-		p.SendPacket(packet)
+		if p != nil {
+			ps = append(ps, p)
+		}
 	}
 	r.mu.RUnlock()
+	return ps
 }
 
 func (r *room) Closed() bool {
@@ -425,25 +920,57 @@ func (r *room) Close() error {
 	}
 	r.state = RoomStateClose
 
-	for _, p := range r.players {
+	var players = make([]int64, 0, len(r.players))
+	for playerId, p := range r.players {
 		if p != nil {
-			r.enqueuePlayerOut(p.GetId(), nil)
+			players = append(players, playerId)
 		}
 	}
+	var mode = r.mode
+	r.mu.Unlock()
+
+	// 队列关闭之前，房间的消息处理协程不会结束，所以这里不需要持有 r.mu
+	for _, playerId := range players {
+		r.enqueuePlayerOut(playerId, nil, newLeaveError(LeaveReasonShutdown, nil))
+	}
 	//if r.queue != nil {
 	//	r.queue.Enqueue(nil)
 	//}
 	r.queue.Close()
-	r.mu.Unlock()
+
+	r.stopTimers()
 
 	var err error
-	if r.mode != nil {
-		err = r.mode.OnClose()
+	if mode != nil {
+		err = mode.OnClose()
 	}
 	return err
 }
 
+func (r *room) Shutdown(ctx context.Context) error {
+	var err = r.Close()
+
+	r.mu.RLock()
+	var done = r.done
+	r.mu.RUnlock()
+
+	// 房间没有运行过
+	if done == nil {
+		return err
+	}
+
+	select {
+	case <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (r *room) panic(game Game, err error) {
+	if r.metrics != nil {
+		r.metrics.IncPanic(r.id)
+	}
 	game.OnPanic(r, err)
 
 	r.mu.Lock()
@@ -460,28 +987,55 @@ func (r *room) panic(game Game, err error) {
 	//	}
 	//}
 
-	for _, p := range r.players {
-		if p == nil {
-			continue
-		}
-		delete(r.players, p.GetId())
-		r.mu.Unlock()
-
-		p.Close()
-		game.OnLeaveRoom(p, nil)
-
-		r.mu.Lock()
+	var players = make([]int64, 0, len(r.players))
+	for playerId := range r.players {
+		players = append(players, playerId)
 	}
 	r.mu.Unlock()
 
+	// 和正常离开房间一样，交由拦截器链处理
+	for _, playerId := range players {
+		r.onLeaveRoom(game, playerId, newLeaveError(LeaveReasonShutdown, nil))
+	}
+
 	if r.mode != nil {
 		r.mode.OnClose()
 	}
 }
 
 func (r *room) clean() {
+	// 关闭队列，唤醒因为 QueuePolicyBlock 而阻塞的连接，发生异常的时候房间也会经过这里
+	r.queue.Close()
+
+	for _, d := range r.disconnected {
+		d.timer.Stop()
+	}
+	r.disconnected = nil
+
+	r.stopTimers()
+
+	if r.idle != nil && r.idle.timer != nil {
+		r.idle.timer.Stop()
+	}
+
+	if r.metrics != nil {
+		r.metrics.RemoveRoom(r.id)
+	}
+
+	r.mu.Lock()
+	var observers = r.observers
+	r.observers = nil
+	r.mu.Unlock()
+	for _, o := range observers {
+		r.closeObserver(o)
+	}
+
+	r.mu.Lock()
 	r.players = nil
+	r.banned = nil
+	r.pauseBuffer = nil
 	r.messagePool = nil
 	r.mode = nil
+	r.mu.Unlock()
 	close(r.closed)
 }
@@ -7,14 +7,20 @@ import (
 
 type asyncRoom struct {
 	*room
+	tickChanged chan struct{}
 }
 
 func newAsyncRoom(room *room) roomMode {
 	var r = &asyncRoom{}
 	r.room = room
+	r.tickChanged = make(chan struct{}, 1)
 	return r
 }
 
+func (r *asyncRoom) Check(game Game) error {
+	return nil
+}
+
 func (r *asyncRoom) Run(game Game) (err error) {
 	//if game == nil {
 	//	return ErrNilGame
@@ -36,14 +42,13 @@ func (r *asyncRoom) Run(game Game) (err error) {
 	//
 	//game.OnRunInRoom(r)
 
-	var stopTicker = make(chan struct{}, 1)
-	var tickerDone = make(chan struct{}, 1)
+	var stopTicker = make(chan struct{})
+	var tickerDone = make(chan struct{})
+	var tickErr error
 
 	var mList []*message
 
 	defer func() {
-		close(stopTicker)
-		<-tickerDone
 		game.OnCloseRoom(r)
 		r.clean()
 	}()
@@ -56,40 +61,43 @@ func (r *asyncRoom) Run(game Game) (err error) {
 		}
 	}()
 
+	// 处理异常之前需要先停止定时器，避免 OnTick 和 OnLeaveRoom 等方法同时执行
+	var stopped bool
+	var stop = func() {
+		if !stopped {
+			stopped = true
+			close(stopTicker)
+			<-tickerDone
+		}
+	}
+	defer stop()
+
 	go func() {
+		defer close(tickerDone)
 		defer func() {
 			if v := recover(); v != nil {
-				err = newStackError(v, debug.Stack())
-
-				r.room.panic(game, err)
+				// 定时器协程中的异常交由消息处理协程处理
+				tickErr = newStackError(v, debug.Stack())
 
 				r.queue.Close()
 			}
 		}()
 
-		r.tick(game, stopTicker, tickerDone)
+		r.tick(game, stopTicker)
 	}()
 
 RunLoop:
 	for {
 		mList = mList[0:0]
 		var ok = r.queue.Dequeue(&mList)
+		r.observeDequeue(mList)
 
 		for _, m := range mList {
 			//if m == nil {
 			//	break RunLoop
 			//}
 
-			switch m.Type {
-			case mTypeDefault:
-				r.onMessage(game, m.PlayerId, m.Data)
-			case mTypeCustom:
-				r.onDequeue(game, m.Data)
-			case mTypePlayerIn:
-				m.rError <- r.onJoinRoom(game, m.Player)
-			case mTypePlayerOut:
-				r.onLeaveRoom(game, m.PlayerId, m.Error)
-			}
+			r.dispatch(game, m)
 			r.releaseMessage(m)
 		}
 
@@ -97,36 +105,64 @@ RunLoop:
 			break RunLoop
 		}
 	}
+
+	stop()
+	if tickErr != nil {
+		err = tickErr
+		r.room.panic(game, err)
+	}
 	return
 }
 
-func (r *asyncRoom) tick(game Game, stopTicker chan struct{}, tickerDone chan struct{}) {
-	var t = game.TickInterval()
-	if t <= 0 {
-		return
-	}
+func (r *asyncRoom) tick(game Game, stopTicker chan struct{}) {
+	r.ticker = newTickScheduler(r.clock, r.tickPolicy, TickPolicySkip)
 
-	var ticker = time.NewTicker(t)
+	var timer = r.clock.NewTimer(r.ticker.reset(r.currentTickInterval()))
+	defer timer.Stop()
 
-	defer func() {
-		ticker.Stop()
-		close(tickerDone)
-	}()
+	var reset = func(d time.Duration) {
+		if !timer.Stop() {
+			select {
+			case <-timer.C():
+			default:
+			}
+		}
+		if !r.ticker.paused {
+			timer.Reset(d)
+		}
+	}
+
+	if r.ticker.paused {
+		reset(0)
+	}
 
 TickLoop:
 	for {
 		select {
 		case <-stopTicker:
 			break TickLoop
-		case <-ticker.C:
+		case <-r.tickChanged:
+			reset(r.ticker.reset(r.currentTickInterval()))
+		case <-timer.C():
 			if r.Closed() {
 				break TickLoop
 			}
-			game.OnTick()
+			if r.ticker.paused {
+				continue
+			}
+			r.ticker.begin()
+			timer.Reset(r.onTick(game))
 		}
 	}
 }
 
+func (r *asyncRoom) OnTickInterval() {
+	select {
+	case r.tickChanged <- struct{}{}:
+	default:
+	}
+}
+
 func (r *asyncRoom) OnClose() error {
 	return nil
 }
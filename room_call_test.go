@@ -0,0 +1,46 @@
+package newbee_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/smartwalle/newbee"
+	"github.com/smartwalle/newbee/newbeetest"
+)
+
+// callGame 实现了 CallHandler，OnCall 会返回请求的内容
+type callGame struct {
+	*newbeetest.Game
+}
+
+func (g callGame) OnCall(request interface{}) (interface{}, error) {
+	if err, ok := request.(error); ok {
+		return nil, err
+	}
+	return request, nil
+}
+
+func TestCall(t *testing.T) {
+	for name, mode := range newbeetest.Modes() {
+		t.Run(name, func(t *testing.T) {
+			var game = callGame{newbeetest.NewGame(1)}
+			var room = newbeetest.RunRoom(t, game, mode)
+
+			var response, err = room.Call(context.Background(), "ping")
+			if err != nil || response != "ping" {
+				t.Fatalf("got (%v, %v), want (ping, nil)", response, err)
+			}
+
+			var errFailed = errors.New("failed")
+			if _, err = room.Call(context.Background(), errFailed); err != errFailed {
+				t.Fatalf("got %v, want %v", err, errFailed)
+			}
+		})
+	}
+
+	var room = newbeetest.RunRoom(t, plainGame{newbeetest.NewGame(1)}, newbee.WithSync())
+	if _, err := room.Call(context.Background(), "ping"); !errors.Is(err, newbee.ErrNotCallHandler) {
+		t.Fatalf("got %v, want %v", err, newbee.ErrNotCallHandler)
+	}
+}
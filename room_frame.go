@@ -7,15 +7,44 @@ import (
 
 type frameRoom struct {
 	*room
-	timer *time.Timer
+	timer       ClockTimer
+	frame       FrameHandler
+	inputs      []bufferedMessage
+	tickChanged chan struct{}
+	closing     chan struct{}
+	lockstep    bool
 }
 
 func newFrameRoom(room *room) roomMode {
 	var r = &frameRoom{}
 	r.room = room
+	r.tickChanged = make(chan struct{}, 1)
+	r.closing = make(chan struct{}, 1)
 	return r
 }
 
+func newLockstepRoom(room *room) roomMode {
+	var r = &frameRoom{}
+	r.room = room
+	r.tickChanged = make(chan struct{}, 1)
+	r.closing = make(chan struct{}, 1)
+	r.lockstep = true
+	return r
+}
+
+func (r *frameRoom) Check(game Game) error {
+	if game.TickInterval() <= 0 {
+		return ErrBadInterval
+	}
+
+	if r.lockstep {
+		if _, ok := game.(FrameHandler); !ok {
+			return ErrNotFrameHandler
+		}
+	}
+	return nil
+}
+
 func (r *frameRoom) Run(game Game) (err error) {
 	//if game == nil {
 	//	return ErrNilGame
@@ -37,11 +66,13 @@ func (r *frameRoom) Run(game Game) (err error) {
 	//
 	//game.OnRunInRoom(r)
 
-	var d = game.TickInterval()
-	if d <= 0 {
-		return ErrBadInterval
+	if r.lockstep {
+		r.frame = game.(FrameHandler)
+		r.room.collect = r.collect
 	}
-	r.tick(d)
+
+	r.ticker = newTickScheduler(r.clock, r.tickPolicy, TickPolicyFixedDelay)
+	r.tick(r.ticker.reset(r.GetTickInterval()))
 
 	var mList []*message
 
@@ -65,46 +96,79 @@ func (r *frameRoom) Run(game Game) (err error) {
 RunLoop:
 	for {
 		select {
-		case <-r.timer.C:
-			mList = mList[0:0]
-			var ok = r.queue.Dequeue(&mList)
-
-			for _, m := range mList {
-				//if m == nil {
-				//	break RunLoop
-				//}
-
-				switch m.Type {
-				case mTypeDefault:
-					r.onMessage(game, m.PlayerId, m.Data)
-				case mTypeCustom:
-					r.onDequeue(game, m.Data)
-				case mTypePlayerIn:
-					m.rError <- r.onJoinRoom(game, m.Player)
-				case mTypePlayerOut:
-					r.onLeaveRoom(game, m.PlayerId, m.Error)
-				}
-				r.releaseMessage(m)
+		case <-r.closing:
+			// 房间关闭之后不再等待定时器，直接处理队列中剩余的消息
+			r.process(game, &mList)
+			break RunLoop
+		case <-r.tickChanged:
+			r.ticker.reset(r.currentTickInterval())
+			r.tick(r.ticker.interval)
+		case <-r.timer.C():
+			// 暂停 tick 之后，仍然按照之前的时间间隔处理队列中的消息
+			var paused = r.ticker.paused
+			if !paused {
+				r.ticker.begin()
+				r.frameId++
 			}
 
-			if !ok {
+			if !r.process(game, &mList) {
 				break RunLoop
 			}
 
-			game.OnTick()
-			r.tick(d)
+			if paused {
+				r.tick(r.ticker.interval)
+				continue
+			}
+
+			if r.lockstep {
+				r.onFrame()
+			}
+
+			r.tick(r.onTick(game))
 		}
 	}
 	return
 }
 
+// process 处理队列中的消息，队列关闭之后返回 false
+func (r *frameRoom) process(game Game, mList *[]*message) bool {
+	*mList = (*mList)[0:0]
+	var ok = r.queue.Dequeue(mList)
+	r.observeDequeue(*mList)
+
+	for _, m := range *mList {
+		r.dispatch(game, m)
+		r.releaseMessage(m)
+	}
+	return ok
+}
+
+// collect 收集玩家在当前帧的输入，玩家消息经过拦截器链之后会调用此方法
+func (r *frameRoom) collect(player Player, message interface{}) {
+	r.inputs = r.bufferMessage(r.inputs, bufferedMessage{playerId: player.GetId(), data: message})
+}
+
+func (r *frameRoom) onFrame() {
+	var inputs = make(map[int64][]interface{})
+	for _, input := range r.inputs {
+		inputs[input.playerId] = append(inputs[input.playerId], input.data)
+	}
+	r.inputs = nil
+
+	r.record(RecordTypeFrame, 0, inputs, nil)
+
+	if packet := r.frame.OnFrame(r.frameId, inputs); packet != nil {
+		r.BroadcastPacketWithObservers(packet)
+	}
+}
+
 func (r *frameRoom) tick(d time.Duration) {
 	if r.timer == nil {
-		r.timer = time.NewTimer(d)
+		r.timer = r.clock.NewTimer(d)
 	} else {
 		if !r.timer.Stop() {
 			select {
-			case <-r.timer.C:
+			case <-r.timer.C():
 			default:
 			}
 		}
@@ -112,6 +176,17 @@ func (r *frameRoom) tick(d time.Duration) {
 	}
 }
 
+func (r *frameRoom) OnTickInterval() {
+	select {
+	case r.tickChanged <- struct{}{}:
+	default:
+	}
+}
+
 func (r *frameRoom) OnClose() error {
+	select {
+	case r.closing <- struct{}{}:
+	default:
+	}
 	return nil
 }
@@ -0,0 +1,95 @@
+package newbee_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/smartwalle/net4go"
+	"github.com/smartwalle/newbee"
+	"github.com/smartwalle/newbee/newbeetest"
+)
+
+func TestRunLockstepWithoutFrameHandler(t *testing.T) {
+	var room = newbee.NewRoom(1, newbee.WithLockstep())
+
+	if err := room.Run(plainGame{newbeetest.NewGame(1)}); !errors.Is(err, newbee.ErrNotFrameHandler) {
+		t.Fatalf("got %v, want %v", err, newbee.ErrNotFrameHandler)
+	}
+	if room.GetState() != newbee.RoomStatePending {
+		t.Fatalf("got state %d, want %d", room.GetState(), newbee.RoomStatePending)
+	}
+
+	// 房间没有运行，不能阻塞
+	if err := room.AddPlayer(newbee.NewPlayer(1, newbeetest.NewSession())); !errors.Is(err, newbee.ErrRoomNotRunning) {
+		t.Fatalf("got %v, want %v", err, newbee.ErrRoomNotRunning)
+	}
+	if _, err := room.Call(context.Background(), 1); !errors.Is(err, newbee.ErrRoomNotRunning) {
+		t.Fatalf("got %v, want %v", err, newbee.ErrRoomNotRunning)
+	}
+}
+
+// frameGame 收到玩家输入的帧会向所有玩家广播一条消息
+type frameGame struct {
+	*newbeetest.Game
+}
+
+func (g frameGame) OnFrame(frameId uint64, inputs map[int64][]interface{}) net4go.Packet {
+	g.Game.OnFrame(frameId, inputs)
+	if len(inputs) == 0 {
+		return nil
+	}
+	return newPacket("frame")
+}
+
+func TestLockstep(t *testing.T) {
+	var game = frameGame{newbeetest.NewGame(1)}
+	var room = newbeetest.RunRoom(t, game, newbee.WithLockstep())
+	var sessions = newbeetest.JoinPlayers(t, room, 2)
+
+	var observer = newbeetest.NewSession()
+	if err := room.AddObserver(newbee.NewPlayer(10, observer)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 玩家的输入不会交由 OnMessage 处理，而是在下一帧交由 OnFrame 处理
+	room.Pause()
+	sessions[0].Inject(newPacket("a"))
+	sessions[1].Inject(newPacket("b"))
+	sessions[0].Inject(newPacket("c"))
+	room.Resume()
+	newbeetest.ExpectCalls(t, game.Game, "OnRunInRoom", "OnJoinRoom", "OnJoinRoom", "OnPause", "OnResume", "OnFrame", "OnFrame", "OnFrame")
+
+	// 同一帧中不同玩家的输入没有固定的顺序，同一个玩家的输入保持收到的顺序
+	var inputs = make(map[int64][]string)
+	for _, call := range game.Calls()[5:] {
+		inputs[call.PlayerId] = append(inputs[call.PlayerId], packetData(call.Message))
+	}
+	if len(inputs[1]) != 2 || inputs[1][0] != "a" || inputs[1][1] != "c" || len(inputs[2]) != 1 || inputs[2][0] != "b" {
+		t.Fatalf("got %v, want map[1:[a c] 2:[b]]", inputs)
+	}
+
+	// OnFrame 的返回值会广播给所有玩家和观察者
+	waitFor(t, "the frame packet", func() bool { return len(observer.Packets()) == 1 })
+	for _, sess := range sessions {
+		if packets := sess.Packets(); len(packets) != 1 || packetData(packets[0]) != "frame" {
+			t.Fatalf("got %v, want the frame packet", packets)
+		}
+	}
+}
+
+func TestFrame(t *testing.T) {
+	var game = newbeetest.NewGame(1)
+	var room = newbeetest.RunRoom(t, game, newbee.WithFrame())
+	var sessions = newbeetest.JoinPlayers(t, room, 1)
+
+	// WithFrame 模式下玩家消息按照收到的顺序在每一帧开始的时候交由 OnMessage 处理
+	sessions[0].Inject(newPacket("a"))
+	sessions[0].Inject(newPacket("b"))
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnMessage", "OnMessage")
+
+	if a, b := packetData(game.Calls()[2].Message), packetData(game.Calls()[3].Message); a != "a" || b != "b" {
+		t.Fatalf("got [%s %s], want [a b]", a, b)
+	}
+	waitFor(t, "ticks", func() bool { return game.Ticks() > 0 })
+}
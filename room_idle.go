@@ -0,0 +1,135 @@
+package newbee
+
+import (
+	"sync"
+	"time"
+)
+
+type IdleAction int
+
+const (
+	IdleActionNotify IdleAction = iota // 交由 IdleHandler 的 OnPlayerIdle 方法处理，玩家再次发送消息之前只会通知一次
+	IdleActionRemove                   // 将玩家移出房间，OnLeaveRoom 收到的离开原因为 LeaveReasonIdle
+)
+
+type idlePlayer struct {
+	last     time.Time
+	notified bool
+}
+
+// idleTracker 记录玩家最后一次发送消息的时间
+// 机器人和断线等待重连的玩家不会被记录
+type idleTracker struct {
+	players map[int64]*idlePlayer
+	timer   ClockTimer
+	timeout time.Duration
+	action  IdleAction
+	mu      sync.Mutex
+}
+
+func newIdleTracker(timeout time.Duration, action IdleAction) *idleTracker {
+	var t = &idleTracker{}
+	t.players = make(map[int64]*idlePlayer)
+	t.timeout = timeout
+	t.action = action
+	return t
+}
+
+func (t *idleTracker) add(playerId int64, now time.Time) {
+	t.mu.Lock()
+	t.players[playerId] = &idlePlayer{last: now}
+	t.mu.Unlock()
+}
+
+func (t *idleTracker) remove(playerId int64) {
+	t.mu.Lock()
+	delete(t.players, playerId)
+	t.mu.Unlock()
+}
+
+// touch 更新玩家最后一次发送消息的时间，只更新已经记录的玩家
+func (t *idleTracker) touch(playerId int64, now time.Time) {
+	t.mu.Lock()
+	if p, ok := t.players[playerId]; ok {
+		p.last = now
+		p.notified = false
+	}
+	t.mu.Unlock()
+}
+
+func (t *idleTracker) touchAll(now time.Time) {
+	t.mu.Lock()
+	for _, p := range t.players {
+		p.last = now
+		p.notified = false
+	}
+	t.mu.Unlock()
+}
+
+// expired 返回已经超时的玩家以及对应的空闲时间，next 为距离下一个玩家超时的时间
+func (t *idleTracker) expired(now time.Time) (players map[int64]time.Duration, next time.Duration) {
+	next = t.timeout
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for playerId, p := range t.players {
+		if p.notified {
+			continue
+		}
+
+		var idle = now.Sub(p.last)
+		if idle >= t.timeout {
+			if players == nil {
+				players = make(map[int64]time.Duration)
+			}
+			players[playerId] = idle
+			p.notified = true
+			continue
+		}
+
+		if remain := t.timeout - idle; remain < next {
+			next = remain
+		}
+	}
+	return players, next
+}
+
+func (r *room) scheduleIdleCheck(d time.Duration) {
+	r.idle.timer = r.clock.AfterFunc(d, func() {
+		var m = r.newMessage(0, mTypeIdleCheck, nil, nil)
+		if m != nil {
+			r.queue.Enqueue(m)
+		}
+	})
+}
+
+func (r *room) onIdleCheck(game Game) {
+	// 暂停期间不检查，恢复的时候会重置所有玩家的时间
+	if r.paused {
+		r.scheduleIdleCheck(r.idle.timeout)
+		return
+	}
+
+	var players, next = r.idle.expired(r.clock.Now())
+	for playerId, idle := range players {
+		var p = r.GetPlayer(playerId)
+		if p == nil {
+			r.idle.remove(playerId)
+			continue
+		}
+
+		if r.idle.action == IdleActionRemove {
+			var err = newLeaveError(LeaveReasonIdle, nil)
+			r.record(RecordTypePlayerOut, playerId, nil, err)
+			r.onLeaveRoom(game, playerId, err)
+			continue
+		}
+
+		r.record(RecordTypePlayerIdle, playerId, idle, nil)
+		if h, ok := game.(IdleHandler); ok {
+			h.OnPlayerIdle(p, idle)
+		}
+	}
+	r.scheduleIdleCheck(next)
+}
@@ -0,0 +1,54 @@
+package newbee_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/smartwalle/newbee"
+	"github.com/smartwalle/newbee/newbeetest"
+)
+
+func TestIdleNotify(t *testing.T) {
+	var clock = newbee.NewFakeClock(time.Now())
+	var game = newbeetest.NewGame(1)
+	game.Interval = 0
+	var room = newbeetest.RunRoom(t, game, newbee.WithAsync(), newbee.WithClock(clock), newbee.WithIdleTimeout(time.Second, newbee.IdleActionNotify))
+	var sessions = newbeetest.JoinPlayers(t, room, 2)
+
+	// 机器人不会空闲超时
+	if err := room.AddPlayer(newbee.NewBot(3, nil)); err != nil {
+		t.Fatal(err)
+	}
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnJoinRoom", "OnJoinRoom")
+
+	clock.Advance(time.Millisecond * 500)
+	sessions[1].Inject(newPacket("active"))
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnJoinRoom", "OnJoinRoom", "OnMessage")
+
+	clock.Advance(time.Millisecond * 500)
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnJoinRoom", "OnJoinRoom", "OnMessage", "OnPlayerIdle")
+	if call := game.Calls()[5]; call.PlayerId != 1 || call.Message != time.Second {
+		t.Fatalf("got %+v, want player 1 idle for 1s", call)
+	}
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Millisecond * 500)
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnJoinRoom", "OnJoinRoom", "OnMessage", "OnPlayerIdle", "OnPlayerIdle")
+	if call := game.Calls()[6]; call.PlayerId != 2 || call.Message != time.Second {
+		t.Fatalf("got %+v, want player 2 idle for 1s", call)
+	}
+}
+
+func TestIdleRemove(t *testing.T) {
+	var clock = newbee.NewFakeClock(time.Now())
+	var game = newbeetest.NewGame(1)
+	game.Interval = 0
+	var room = newbeetest.RunRoom(t, game, newbee.WithSync(), newbee.WithClock(clock), newbee.WithIdleTimeout(time.Second, newbee.IdleActionRemove))
+	newbeetest.JoinPlayers(t, room, 1)
+
+	clock.Advance(time.Second)
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnLeaveRoom")
+	if reason := newbee.GetLeaveReason(game.Calls()[2].Error); reason != newbee.LeaveReasonIdle {
+		t.Fatalf("got reason %s, want %s", reason, newbee.LeaveReasonIdle)
+	}
+}
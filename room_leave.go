@@ -0,0 +1,97 @@
+package newbee
+
+import (
+	"errors"
+	"github.com/smartwalle/net4go"
+)
+
+type LeaveReason int
+
+const (
+	LeaveReasonUnknown          LeaveReason = iota // 未知原因
+	LeaveReasonRemoved                             // 通过 Room 的 RemovePlayer 方法移除
+	LeaveReasonDisconnect                          // 网络连接断开
+	LeaveReasonReconnectTimeout                    // 断线之后没有在 WithReconnectTimeout 设置的时间内重连
+	LeaveReasonKick                                // 通过 Room 的 KickPlayer 方法踢出
+	LeaveReasonBan                                 // 通过 Room 的 BanPlayer 方法封禁
+	LeaveReasonShutdown                            // 房间关闭
+	LeaveReasonIdle                                // 超过 WithIdleTimeout 设置的时间没有发送消息
+)
+
+func (r LeaveReason) String() string {
+	switch r {
+	case LeaveReasonRemoved:
+		return "removed"
+	case LeaveReasonDisconnect:
+		return "disconnect"
+	case LeaveReasonReconnectTimeout:
+		return "reconnect timeout"
+	case LeaveReasonKick:
+		return "kick"
+	case LeaveReasonBan:
+		return "ban"
+	case LeaveReasonShutdown:
+		return "shutdown"
+	case LeaveReasonIdle:
+		return "idle"
+	}
+	return "unknown"
+}
+
+// LeaveError 玩家离开房间的原因，Room 调用 Game 的 OnLeaveRoom 方法时传入的 err 总是 *LeaveError
+type LeaveError struct {
+	Reason LeaveReason
+	Err    error // 导致玩家离开的错误信息，例如网络连接断开的原因，可能为 nil
+}
+
+func newLeaveError(reason LeaveReason, err error) *LeaveError {
+	return &LeaveError{Reason: reason, Err: err}
+}
+
+func (e *LeaveError) Error() string {
+	if e.Err != nil {
+		return "newbee: player left, reason: " + e.Reason.String() + ", " + e.Err.Error()
+	}
+	return "newbee: player left, reason: " + e.Reason.String()
+}
+
+func (e *LeaveError) Unwrap() error {
+	return e.Err
+}
+
+// GetLeaveReason 获取 OnLeaveRoom 方法的 err 中记录的离开原因
+func GetLeaveReason(err error) LeaveReason {
+	var e *LeaveError
+	if errors.As(err, &e) {
+		return e.Reason
+	}
+	return LeaveReasonUnknown
+}
+
+func (r *room) KickPlayer(playerId int64, reason LeaveReason, packet net4go.Packet) {
+	r.enqueuePlayerOut(playerId, packet, newLeaveError(reason, nil))
+}
+
+func (r *room) BanPlayer(playerId int64, packet net4go.Packet) {
+	r.mu.Lock()
+	if r.banned == nil {
+		r.banned = make(map[int64]struct{})
+	}
+	r.banned[playerId] = struct{}{}
+	r.mu.Unlock()
+
+	r.enqueuePlayerOut(playerId, packet, newLeaveError(LeaveReasonBan, nil))
+}
+
+func (r *room) UnbanPlayer(playerId int64) {
+	r.mu.Lock()
+	delete(r.banned, playerId)
+	r.mu.Unlock()
+}
+
+func (r *room) IsBanned(playerId int64) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var _, ok = r.banned[playerId]
+	return ok
+}
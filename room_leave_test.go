@@ -0,0 +1,71 @@
+package newbee_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/smartwalle/newbee"
+	"github.com/smartwalle/newbee/newbeetest"
+)
+
+func TestLeaveReason(t *testing.T) {
+	for name, mode := range newbeetest.Modes() {
+		t.Run(name, func(t *testing.T) {
+			var game = newbeetest.NewGame(1)
+			var room = newbeetest.RunRoom(t, game, mode)
+			var sessions = newbeetest.JoinPlayers(t, room, 3)
+
+			room.RemovePlayer(1)
+			newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnJoinRoom", "OnJoinRoom", "OnLeaveRoom")
+
+			var bye = newPacket("bye")
+			room.KickPlayer(2, newbee.LeaveReasonKick, bye)
+			newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnJoinRoom", "OnJoinRoom", "OnLeaveRoom", "OnLeaveRoom")
+
+			// 踢出玩家之前发送 packet
+			if packets := sessions[1].Packets(); len(packets) != 1 || packets[0] != bye {
+				t.Fatalf("got %v, want the kick packet", packets)
+			}
+			if !sessions[1].Closed() {
+				t.Fatal("kicked player's session was not closed")
+			}
+
+			sessions[2].Close()
+			newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnJoinRoom", "OnJoinRoom", "OnLeaveRoom", "OnLeaveRoom", "OnLeaveRoom")
+
+			var want = []newbee.LeaveReason{newbee.LeaveReasonRemoved, newbee.LeaveReasonKick, newbee.LeaveReasonDisconnect}
+			for i, call := range game.Calls()[4:] {
+				if reason := newbee.GetLeaveReason(call.Error); call.PlayerId != int64(i+1) || reason != want[i] {
+					t.Fatalf("player %d left with %s, want player %d to leave with %s", call.PlayerId, reason, i+1, want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBanPlayer(t *testing.T) {
+	var game = newbeetest.NewGame(1)
+	var room = newbeetest.RunRoom(t, game, newbee.WithSync())
+	var sessions = newbeetest.JoinPlayers(t, room, 1)
+
+	room.BanPlayer(1, newPacket("banned"))
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnLeaveRoom")
+
+	if reason := newbee.GetLeaveReason(game.Calls()[2].Error); reason != newbee.LeaveReasonBan {
+		t.Fatalf("got reason %s, want %s", reason, newbee.LeaveReasonBan)
+	}
+	if len(sessions[0].Packets()) != 1 || !sessions[0].Closed() {
+		t.Fatal("banned player did not receive the packet before the session was closed")
+	}
+	if !room.IsBanned(1) {
+		t.Fatal("IsBanned returned false for a banned player")
+	}
+	if err := room.AddPlayer(newbee.NewPlayer(1, newbeetest.NewSession())); !errors.Is(err, newbee.ErrPlayerBanned) {
+		t.Fatalf("got %v, want %v", err, newbee.ErrPlayerBanned)
+	}
+
+	room.UnbanPlayer(1)
+	if err := room.AddPlayer(newbee.NewPlayer(1, newbeetest.NewSession())); err != nil {
+		t.Fatal(err)
+	}
+}
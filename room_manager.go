@@ -0,0 +1,155 @@
+package newbee
+
+import (
+	"context"
+	"sync"
+)
+
+type RoomManagerOption func(m *roomManager)
+
+// WithRoomDoneHandler 房间的 Run() 方法返回之后（房间已从 RoomManager 中移除）会调用 handler
+func WithRoomDoneHandler(handler func(room Room, err error)) RoomManagerOption {
+	return func(m *roomManager) {
+		m.onDone = handler
+	}
+}
+
+type RoomManager interface {
+	// RunRoom 创建并运行房间，房间的 Run() 方法返回之后会自动从 RoomManager 中移除
+	// 房间不能运行的时候（例如 WithLockstep 模式下 game 没有实现 FrameHandler）会直接返回相应的错误信息
+	// 本方法返回的时候房间已经进入 RoomStateRunning 状态，可以直接调用房间的 AddPlayer() 方法
+	RunRoom(id int64, game Game, opts ...RoomOption) (Room, error)
+
+	// Get 获取房间信息
+	Get(id int64) Room
+
+	// Range 遍历房间信息，回调函数返回 false 的时候将停止遍历
+	Range(fn func(room Room) bool)
+
+	// Count 获取房间数量
+	Count() int
+
+	// Shutdown 关闭所有房间，并等待所有房间的 Run() 方法返回，如果 ctx 先结束，则返回 ctx.Err()
+	Shutdown(ctx context.Context) error
+}
+
+type roomManager struct {
+	onDone func(room Room, err error)
+	rooms  map[int64]Room
+	waiter *sync.WaitGroup
+	mu     sync.RWMutex
+	closed bool
+}
+
+func NewRoomManager(opts ...RoomManagerOption) RoomManager {
+	var m = &roomManager{}
+	m.rooms = make(map[int64]Room)
+	m.waiter = &sync.WaitGroup{}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(m)
+		}
+	}
+	return m
+}
+
+func (m *roomManager) RunRoom(id int64, game Game, opts ...RoomOption) (Room, error) {
+	if game == nil {
+		return nil, ErrNilGame
+	}
+
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil, ErrManagerClosed
+	}
+
+	if _, ok := m.rooms[id]; ok {
+		m.mu.Unlock()
+		return nil, ErrRoomExists
+	}
+
+	var r = NewRoom(id, opts...).(*room)
+	if err := r.start(game); err != nil {
+		m.mu.Unlock()
+		return nil, err
+	}
+	m.rooms[id] = r
+	m.waiter.Add(1)
+	m.mu.Unlock()
+
+	go func() {
+		defer m.waiter.Done()
+
+		var err = r.run(game)
+
+		m.mu.Lock()
+		if m.rooms[id] == Room(r) {
+			delete(m.rooms, id)
+		}
+		m.mu.Unlock()
+
+		if m.onDone != nil {
+			m.onDone(r, err)
+		}
+	}()
+
+	return r, nil
+}
+
+func (m *roomManager) Get(id int64) Room {
+	m.mu.RLock()
+	var r = m.rooms[id]
+	m.mu.RUnlock()
+	return r
+}
+
+func (m *roomManager) Range(fn func(room Room) bool) {
+	m.mu.RLock()
+	var rooms = make([]Room, 0, len(m.rooms))
+	for _, r := range m.rooms {
+		rooms = append(rooms, r)
+	}
+	m.mu.RUnlock()
+
+	for _, r := range rooms {
+		if !fn(r) {
+			return
+		}
+	}
+}
+
+func (m *roomManager) Count() int {
+	m.mu.RLock()
+	var c = len(m.rooms)
+	m.mu.RUnlock()
+	return c
+}
+
+func (m *roomManager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	m.closed = true
+	var rooms = make([]Room, 0, len(m.rooms))
+	for _, r := range m.rooms {
+		rooms = append(rooms, r)
+	}
+	m.mu.Unlock()
+
+	for _, r := range rooms {
+		r.Close()
+	}
+
+	var done = make(chan struct{})
+	go func() {
+		m.waiter.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
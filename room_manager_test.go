@@ -0,0 +1,80 @@
+package newbee_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/smartwalle/newbee"
+	"github.com/smartwalle/newbee/newbeetest"
+)
+
+func TestRoomManager(t *testing.T) {
+	var done = make(chan newbee.Room, 2)
+	var manager = newbee.NewRoomManager(newbee.WithRoomDoneHandler(func(room newbee.Room, err error) {
+		done <- room
+	}))
+
+	var game1 = newbeetest.NewGame(1)
+	var room1, err = manager.RunRoom(1, game1, newbee.WithSync())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = manager.RunRoom(2, newbeetest.NewGame(2), newbee.WithFrame()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = manager.RunRoom(1, newbeetest.NewGame(1)); !errors.Is(err, newbee.ErrRoomExists) {
+		t.Fatalf("got %v, want %v", err, newbee.ErrRoomExists)
+	}
+	if manager.Get(1) != room1 || manager.Count() != 2 {
+		t.Fatalf("got %d rooms, want 2", manager.Count())
+	}
+
+	// RunRoom 返回之后可以直接添加玩家
+	newbeetest.JoinPlayers(t, room1, 1)
+
+	room1.Close()
+	if <-done != room1 {
+		t.Fatal("done handler received a different room")
+	}
+	if manager.Get(1) != nil || manager.Count() != 1 {
+		t.Fatal("closed room was not removed from the manager")
+	}
+
+	var ctx, cancel = context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	if err = manager.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if manager.Count() != 0 {
+		t.Fatalf("got %d rooms after shutdown, want 0", manager.Count())
+	}
+	if _, err = manager.RunRoom(3, newbeetest.NewGame(3)); !errors.Is(err, newbee.ErrManagerClosed) {
+		t.Fatalf("got %v, want %v", err, newbee.ErrManagerClosed)
+	}
+	newbeetest.ExpectCalls(t, game1, "OnRunInRoom", "OnJoinRoom", "OnLeaveRoom", "OnCloseRoom")
+}
+
+func TestRoomManagerRunError(t *testing.T) {
+	var manager = newbee.NewRoomManager()
+
+	var room, err = manager.RunRoom(1, plainGame{newbeetest.NewGame(1)}, newbee.WithLockstep())
+	if !errors.Is(err, newbee.ErrNotFrameHandler) || room != nil {
+		t.Fatalf("got %v, want %v", err, newbee.ErrNotFrameHandler)
+	}
+
+	var game = newbeetest.NewGame(2)
+	game.Interval = 0
+	if _, err = manager.RunRoom(2, game, newbee.WithFrame()); !errors.Is(err, newbee.ErrBadInterval) {
+		t.Fatalf("got %v, want %v", err, newbee.ErrBadInterval)
+	}
+
+	if manager.Count() != 0 {
+		t.Fatalf("got %d rooms, want 0", manager.Count())
+	}
+	if err = manager.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,28 +1,164 @@
 package newbee
 
+import (
+	"github.com/smartwalle/net4go"
+)
+
+// bufferedMessage 房间暂停期间缓存的玩家消息，或者 WithLockstep 模式下收集的玩家输入
+type bufferedMessage struct {
+	data     interface{}
+	playerId int64
+}
+
+// bufferMessage 将玩家消息添加到 buffer 中，设置了 WithQueueCapacity 的时候，buffer 的容量和队列的容量相同
+// buffer 已满的时候按照 QueuePolicy 进行处理，本方法在 Room 的消息处理协程中调用，不能阻塞，所以 QueuePolicyBlock 会丢弃新的消息
+func (r *room) bufferMessage(buffer []bufferedMessage, bm bufferedMessage) []bufferedMessage {
+	if r.bQueue == nil || len(buffer) < r.queueCapacity {
+		return append(buffer, bm)
+	}
+	r.bQueue.drop()
+
+	switch r.queuePolicy {
+	case QueuePolicyDropOldest:
+		copy(buffer, buffer[1:])
+		buffer[len(buffer)-1] = bm
+	case QueuePolicyKick:
+		if p := r.GetPlayer(bm.playerId); p != nil {
+			if sess := p.Session(); sess != nil {
+				sess.Close()
+			}
+		}
+	}
+	return buffer
+}
+
+func (r *room) dispatch(game Game, m *message) {
+	if m.Type == mTypeDefault && r.paused {
+		if r.pausePolicy == PausePolicyBuffer {
+			r.pauseBuffer = r.bufferMessage(r.pauseBuffer, bufferedMessage{playerId: m.PlayerId, data: m.Data})
+		}
+		return
+	}
+
+	r.recordMessage(m)
+
+	switch m.Type {
+	case mTypeDefault:
+		r.onMessage(game, m.PlayerId, m.Data)
+	case mTypeCustom:
+		r.onDequeue(game, m.Data)
+	case mTypePlayerIn:
+		m.rError <- r.onJoinRoom(game, m.Player)
+	case mTypePlayerOut:
+		r.onPlayerOut(game, m.PlayerId, m.Data, m.Error)
+	case mTypePlayerDisconnect:
+		r.onDisconnect(game, m.PlayerId, m.Data.(net4go.Session), m.Error)
+	case mTypePlayerReconnect:
+		m.rError <- r.onReconnect(game, m.PlayerId, m.Data.(net4go.Session))
+	case mTypeReconnectTimeout:
+		r.onReconnectTimeout(game, m.PlayerId, m.Data.(*disconnection))
+	case mTypeObserverMessage:
+		r.onObserverMessage(game, m.PlayerId, m.Data)
+	case mTypeObserverOut:
+		r.onObserverOut(m.PlayerId, m.Data.(net4go.Session))
+	case mTypeRateLimited:
+		r.onRateLimited(game, m.PlayerId, m.Data)
+	case mTypeCall:
+		m.rError <- r.onCall(game, m.Data.(*call))
+	case mTypeTimer:
+		r.onTimer(m.Data.(*roomTimer))
+	case mTypePause:
+		r.onPause(game)
+	case mTypeResume:
+		r.onResume(game)
+	case mTypeIdleCheck:
+		r.onIdleCheck(game)
+	}
+
+	r.checkGameState(game)
+}
+
 func (r *room) onMessage(game Game, playerId int64, data interface{}) {
 	var p = r.GetPlayer(playerId)
 	if p == nil {
 		return
 	}
-	game.OnMessage(p, data)
+	r.handler(game, Event{Type: EventMessage, Player: p, Message: data})
+}
+
+func (r *room) onObserverMessage(game Game, observerId int64, data interface{}) {
+	var h, ok = game.(ObserverHandler)
+	if !ok {
+		return
+	}
+
+	var o = r.GetObserver(observerId)
+	if o == nil {
+		return
+	}
+	h.OnObserverMessage(o, data)
+}
+
+func (r *room) onRateLimited(game Game, playerId int64, data interface{}) {
+	var h, ok = game.(RateLimitHandler)
+	if !ok {
+		return
+	}
+
+	var p = r.GetPlayer(playerId)
+	if p == nil {
+		return
+	}
+	h.OnRateLimited(p, data)
+}
+
+func (r *room) onCall(game Game, c *call) error {
+	var h, ok = game.(CallHandler)
+	if !ok {
+		return ErrNotCallHandler
+	}
+	c.response, c.err = h.OnCall(c.request)
+	return nil
 }
 
 func (r *room) onDequeue(game Game, data interface{}) {
-	game.OnDequeue(data)
+	r.handler(game, Event{Type: EventDequeue, Message: data})
 }
 
 func (r *room) onJoinRoom(game Game, player Player) error {
 	if player == nil {
 		return ErrNilPlayer
 	}
-	r.mu.Lock()
+	return r.handler(game, Event{Type: EventJoinRoom, Player: player})
+}
 
-	if _, ok := r.players[player.GetId()]; ok {
-		r.mu.Unlock()
+func (r *room) joinRoom(game Game, player Player) error {
+	r.mu.RLock()
+	var _, exists = r.players[player.GetId()]
+	var _, banned = r.banned[player.GetId()]
+	var full = r.full()
+	r.mu.RUnlock()
+
+	if exists {
 		return ErrPlayerExists
 	}
 
+	if banned {
+		return ErrPlayerBanned
+	}
+
+	if full {
+		return ErrRoomFull
+	}
+
+	if h, ok := game.(JoinRequestHandler); ok {
+		if err := h.OnJoinRequest(player); err != nil {
+			return err
+		}
+	}
+
+	// 玩家列表只会在 Room 的消息处理协程中修改，这里不需要再次检查
+	r.mu.Lock()
 	if player.Connected() {
 		r.players[player.GetId()] = player
 
@@ -32,19 +168,143 @@ func (r *room) onJoinRoom(game Game, player Player) error {
 	}
 	r.mu.Unlock()
 
+	if r.idle != nil && !IsBot(player) {
+		r.idle.add(player.GetId(), r.clock.Now())
+	}
+
+	r.observePlayerCount()
 	game.OnJoinRoom(player)
 	return nil
 }
 
+func (r *room) onPlayerOut(game Game, playerId int64, data interface{}, err error) {
+	if packet, ok := data.(net4go.Packet); ok && packet != nil {
+		if p := r.GetPlayer(playerId); p != nil {
+			p.SendPacket(packet)
+		}
+	}
+	r.onLeaveRoom(game, playerId, err)
+}
+
 func (r *room) onLeaveRoom(game Game, playerId int64, err error) {
+	var p = r.GetPlayer(playerId)
+	if p == nil {
+		return
+	}
+	r.handler(game, Event{Type: EventLeaveRoom, Player: p, Error: err})
+}
+
+func (r *room) leaveRoom(game Game, playerId int64, err error) {
 	var p = r.popPlayer(playerId)
 	if p == nil {
 		return
 	}
 
+	if d, ok := r.disconnected[playerId]; ok {
+		d.timer.Stop()
+		delete(r.disconnected, playerId)
+	}
+
+	if r.limiter != nil {
+		r.limiter.reset(playerId)
+	}
+
+	if r.idle != nil {
+		r.idle.remove(playerId)
+	}
+
 	if p.Connected() {
 		p.Close()
 	}
 
+	r.observePlayerCount()
 	game.OnLeaveRoom(p, err)
 }
+
+func (r *room) onDisconnect(game Game, playerId int64, sess net4go.Session, err error) {
+	var p = r.GetPlayer(playerId)
+	if p == nil {
+		return
+	}
+
+	// 玩家已经通过 ReconnectPlayer 绑定了新的连接，忽略旧连接的断开消息
+	if p.Session() != sess {
+		return
+	}
+	p.Close()
+
+	if d, ok := r.disconnected[playerId]; ok {
+		d.timer.Stop()
+	}
+
+	if r.idle != nil {
+		r.idle.remove(playerId)
+	}
+
+	var d = &disconnection{err: err}
+	d.timer = r.clock.AfterFunc(r.reconnectTimeout, func() {
+		var m = r.newMessage(playerId, mTypeReconnectTimeout, d, nil)
+		if m != nil {
+			r.queue.Enqueue(m)
+		}
+	})
+	r.disconnected[playerId] = d
+
+	if h, ok := game.(ReconnectHandler); ok {
+		h.OnPlayerDisconnected(p, err)
+	}
+}
+
+func (r *room) onReconnect(game Game, playerId int64, sess net4go.Session) error {
+	r.mu.Lock()
+	var p = r.players[playerId]
+	if p == nil {
+		r.mu.Unlock()
+		return ErrPlayerNotExist
+	}
+
+	var updater, ok = p.(SessionUpdater)
+	if !ok {
+		r.mu.Unlock()
+		return ErrNotSessionUpdater
+	}
+
+	if sess.Closed() {
+		r.mu.Unlock()
+		return ErrBadSession
+	}
+
+	var oSess = p.Session()
+	updater.UpdateSession(sess)
+	sess.SetId(playerId)
+	sess.UpdateHandler(r)
+	r.mu.Unlock()
+
+	// 旧连接可能还没有被检测到断开，需要先解除和 Room 的绑定，再将其关闭
+	if oSess != nil && oSess != sess {
+		oSess.UpdateHandler(nil)
+		oSess.Close()
+	}
+
+	if d, ok := r.disconnected[playerId]; ok {
+		d.timer.Stop()
+		delete(r.disconnected, playerId)
+	}
+
+	if r.idle != nil && !IsBot(p) {
+		r.idle.add(playerId, r.clock.Now())
+	}
+
+	if h, ok := game.(ReconnectHandler); ok {
+		h.OnPlayerReconnected(p)
+	}
+	return nil
+}
+
+func (r *room) onReconnectTimeout(game Game, playerId int64, d *disconnection) {
+	// 玩家已经重连或者再次断开（有新的计时器），忽略本次超时
+	if r.disconnected[playerId] != d {
+		return
+	}
+	r.onLeaveRoom(game, playerId, newLeaveError(LeaveReasonReconnectTimeout, d.err))
+}
@@ -0,0 +1,166 @@
+package newbee
+
+import (
+	"github.com/smartwalle/net4go"
+)
+
+// observerHandler 观察者连接的消息处理器，和玩家的连接区分开，避免观察者 id 和玩家 id 冲突
+type observerHandler struct {
+	room *room
+}
+
+func (h *observerHandler) OnMessage(sess net4go.Session, p net4go.Packet) {
+	h.input(sess, p)
+}
+
+// input 处理观察者发送的消息，返回消息是否被放入队列
+func (h *observerHandler) input(sess net4go.Session, p net4go.Packet) bool {
+	var observerId = sess.GetId()
+	if observerId == 0 {
+		sess.Close()
+		return false
+	}
+
+	var m = h.room.newMessage(observerId, mTypeObserverMessage, p, nil)
+	if m == nil {
+		return false
+	}
+	return h.room.enqueueMessage(sess, m)
+}
+
+func (h *observerHandler) OnClose(sess net4go.Session, err error) {
+	var observerId = sess.GetId()
+	if observerId == 0 {
+		return
+	}
+
+	sess.UpdateHandler(nil)
+
+	// 本方法可能在 RangeObserver 的回调中被触发（发送消息失败会关闭连接），所以不能直接操作观察者列表
+	var m = h.room.newMessage(observerId, mTypeObserverOut, sess, err)
+	if m != nil {
+		h.room.queue.Enqueue(m)
+	}
+}
+
+func (r *room) GetObserver(observerId int64) Player {
+	if observerId == 0 {
+		return nil
+	}
+
+	r.mu.RLock()
+	var o = r.observers[observerId]
+	r.mu.RUnlock()
+	return o
+}
+
+func (r *room) RangeObserver(fn func(observer Player)) {
+	r.mu.RLock()
+	for _, o := range r.observers {
+		if o != nil {
+			fn(o)
+		}
+	}
+	r.mu.RUnlock()
+}
+
+func (r *room) GetObserverCount() int {
+	r.mu.RLock()
+	var c = len(r.observers)
+	r.mu.RUnlock()
+	return c
+}
+
+func (r *room) AddObserver(observer Player) error {
+	if observer == nil {
+		return ErrNilPlayer
+	}
+
+	if observer.GetId() == 0 {
+		return ErrInvalidPlayer
+	}
+
+	if !observer.Connected() {
+		return ErrBadSession
+	}
+
+	r.mu.Lock()
+	if !r.active() {
+		r.mu.Unlock()
+		return ErrRoomNotRunning
+	}
+
+	if _, ok := r.observers[observer.GetId()]; ok {
+		r.mu.Unlock()
+		return ErrPlayerExists
+	}
+
+	r.observers[observer.GetId()] = observer
+
+	var sess = observer.Session()
+	sess.SetId(observer.GetId())
+	sess.UpdateHandler(r.observerHandler)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *room) RemoveObserver(observerId int64) {
+	if observerId == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	var o, ok = r.observers[observerId]
+	if ok {
+		delete(r.observers, observerId)
+	}
+	r.mu.Unlock()
+
+	if o != nil {
+		r.closeObserver(o)
+	}
+}
+
+func (r *room) onObserverOut(observerId int64, sess net4go.Session) {
+	r.mu.Lock()
+	if o, ok := r.observers[observerId]; ok && o.Session() == sess {
+		delete(r.observers, observerId)
+	}
+	r.mu.Unlock()
+}
+
+func (r *room) closeObserver(o Player) {
+	if sess := o.Session(); sess != nil {
+		sess.UpdateHandler(nil)
+	}
+	o.Close()
+}
+
+func (r *room) BroadcastPacketWithObservers(packet net4go.Packet) {
+	r.BroadcastPacket(packet)
+
+	if r.observerDelay > 0 {
+		r.clock.AfterFunc(r.observerDelay, func() {
+			r.sendToObservers(packet)
+		})
+		return
+	}
+
+	r.sendToObservers(packet)
+}
+
+// sendToObservers 向所有观察者发送消息，和 BroadcastPacket 一样，发送的时候不持有 r.mu
+func (r *room) sendToObservers(packet net4go.Packet) {
+	r.mu.RLock()
+	var observers = make([]Player, 0, len(r.observers))
+	for _, o := range r.observers {
+		if o != nil {
+			observers = append(observers, o)
+		}
+	}
+	r.mu.RUnlock()
+
+	for _, o := range observers {
+		o.SendPacket(packet)
+	}
+}
@@ -0,0 +1,75 @@
+package newbee_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/smartwalle/newbee"
+	"github.com/smartwalle/newbee/newbeetest"
+)
+
+func TestObserver(t *testing.T) {
+	for name, mode := range newbeetest.Modes() {
+		t.Run(name, func(t *testing.T) {
+			var game = newbeetest.NewGame(1)
+			var room = newbeetest.RunRoom(t, game, mode)
+			var sessions = newbeetest.JoinPlayers(t, room, 1)
+
+			var sess = newbeetest.NewSession()
+			if err := room.AddObserver(newbee.NewPlayer(10, sess)); err != nil {
+				t.Fatal(err)
+			}
+			if err := room.AddObserver(newbee.NewPlayer(10, newbeetest.NewSession())); !errors.Is(err, newbee.ErrPlayerExists) {
+				t.Fatalf("got %v, want %v", err, newbee.ErrPlayerExists)
+			}
+			if room.GetObserverCount() != 1 || room.GetPlayerCount() != 1 {
+				t.Fatalf("got %d observers and %d players, want 1 and 1", room.GetObserverCount(), room.GetPlayerCount())
+			}
+
+			// 观察者的消息交由 OnObserverMessage 处理，lockstep 模式下也不会作为帧输入
+			sess.Inject(newPacket("hi"))
+			newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnObserverMessage")
+			if call := game.Calls()[2]; call.PlayerId != 10 || packetData(call.Message) != "hi" {
+				t.Fatalf("got %+v, want a message from observer 10", call)
+			}
+
+			room.BroadcastPacket(newPacket("players"))
+			room.BroadcastPacketWithObservers(newPacket("all"))
+			if n := len(sessions[0].Packets()); n != 2 {
+				t.Fatalf("player got %d packets, want 2", n)
+			}
+			if packets := sess.Packets(); len(packets) != 1 || packetData(packets[0]) != "all" {
+				t.Fatalf("observer got %v, want [all]", packets)
+			}
+
+			room.RemoveObserver(10)
+			if !sess.Closed() || room.GetObserver(10) != nil {
+				t.Fatal("removed observer is still in the room")
+			}
+		})
+	}
+}
+
+func TestObserverDelay(t *testing.T) {
+	var clock = newbee.NewFakeClock(time.Now())
+	var game = newbeetest.NewGame(1)
+	game.Interval = 0
+	var room = newbeetest.RunRoom(t, game, newbee.WithSync(), newbee.WithClock(clock), newbee.WithObserverDelay(time.Second))
+	var sessions = newbeetest.JoinPlayers(t, room, 1)
+
+	var sess = newbeetest.NewSession()
+	if err := room.AddObserver(newbee.NewPlayer(10, sess)); err != nil {
+		t.Fatal(err)
+	}
+
+	room.BroadcastPacketWithObservers(newPacket("all"))
+	if len(sessions[0].Packets()) != 1 || len(sess.Packets()) != 0 {
+		t.Fatal("observer received the packet without delay")
+	}
+
+	clock.Advance(time.Second)
+	if len(sess.Packets()) != 1 {
+		t.Fatal("observer did not receive the packet after the delay")
+	}
+}
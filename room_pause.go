@@ -0,0 +1,117 @@
+package newbee
+
+import (
+	"time"
+)
+
+type PausePolicy int
+
+const (
+	PausePolicyBuffer PausePolicy = iota // 暂停期间缓存玩家消息，恢复之后再按照收到的顺序交由 Game 处理
+	PausePolicyReject                    // 暂停期间丢弃玩家消息
+)
+
+// Pause 暂停房间，暂停期间不会触发 OnTick 和 OnFrame，玩家消息会根据 WithPausePolicy 设置的策略进行缓存或者丢弃
+// 玩家加入、离开、断线重连以及自定义消息等仍然会正常处理
+func (r *room) Pause() error {
+	r.mu.Lock()
+	if r.state == RoomStatePaused {
+		r.mu.Unlock()
+		return nil
+	}
+	if r.state != RoomStateRunning {
+		r.mu.Unlock()
+		return ErrRoomNotRunning
+	}
+	r.state = RoomStatePaused
+	var mode = r.mode
+	r.mu.Unlock()
+
+	r.enqueuePause(mTypePause)
+	mode.OnTickInterval()
+	return nil
+}
+
+// Resume 恢复暂停的房间
+func (r *room) Resume() error {
+	r.mu.Lock()
+	if r.state == RoomStateRunning {
+		r.mu.Unlock()
+		return nil
+	}
+	if r.state != RoomStatePaused {
+		r.mu.Unlock()
+		return ErrRoomNotRunning
+	}
+	r.state = RoomStateRunning
+	var mode = r.mode
+	r.mu.Unlock()
+
+	r.enqueuePause(mTypeResume)
+	mode.OnTickInterval()
+	return nil
+}
+
+func (r *room) enqueuePause(mType messageType) {
+	var m = r.newMessage(0, mType, nil, nil)
+	if m != nil {
+		r.queue.Enqueue(m)
+	}
+}
+
+// active 房间是否处于运行或者暂停状态，调用之前需要持有 r.mu
+func (r *room) active() bool {
+	return r.state == RoomStateRunning || r.state == RoomStatePaused
+}
+
+// rejectMessage 房间暂停的时候是否需要丢弃玩家消息
+func (r *room) rejectMessage() bool {
+	return r.pausePolicy == PausePolicyReject && r.GetState() == RoomStatePaused
+}
+
+// currentTickInterval 获取当前生效的刷新时间间隔，房间暂停的时候返回 0
+func (r *room) currentTickInterval() time.Duration {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.state == RoomStatePaused {
+		return 0
+	}
+	return r.tickInterval
+}
+
+func (r *room) onPause(game Game) {
+	if r.paused {
+		return
+	}
+	r.paused = true
+
+	if h, ok := game.(PauseHandler); ok {
+		h.OnPause()
+	}
+}
+
+func (r *room) onResume(game Game) {
+	if !r.paused {
+		return
+	}
+	r.paused = false
+
+	if r.idle != nil {
+		r.idle.touchAll(r.clock.Now())
+	}
+
+	if h, ok := game.(PauseHandler); ok {
+		h.OnResume()
+	}
+
+	var buffer = r.pauseBuffer
+	r.pauseBuffer = nil
+	for _, pm := range buffer {
+		var m = r.newMessage(pm.playerId, mTypeDefault, pm.data, nil)
+		if m == nil {
+			return
+		}
+		r.dispatch(game, m)
+		r.releaseMessage(m)
+	}
+}
@@ -0,0 +1,98 @@
+package newbee_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/smartwalle/newbee"
+	"github.com/smartwalle/newbee/newbeetest"
+)
+
+func TestPause(t *testing.T) {
+	for name, mode := range newbeetest.Modes() {
+		if name == "lockstep" {
+			continue
+		}
+
+		t.Run(name, func(t *testing.T) {
+			var game = newbeetest.NewGame(1)
+			var room = newbeetest.RunRoom(t, game, mode)
+			var sessions = newbeetest.JoinPlayers(t, room, 1)
+
+			if err := room.Pause(); err != nil {
+				t.Fatal(err)
+			}
+			if room.GetState() != newbee.RoomStatePaused {
+				t.Fatalf("got state %d, want %d", room.GetState(), newbee.RoomStatePaused)
+			}
+			sessions[0].Inject(newPacket("a"))
+			room.Enqueue("custom")
+			newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnPause", "OnDequeue")
+
+			// 暂停期间不会触发 OnTick
+			var ticks = game.Ticks()
+			time.Sleep(game.Interval * 3)
+			if game.Ticks() != ticks {
+				t.Fatalf("got %d ticks while paused, want %d", game.Ticks(), ticks)
+			}
+
+			if err := room.Resume(); err != nil {
+				t.Fatal(err)
+			}
+			newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnPause", "OnDequeue", "OnResume", "OnMessage")
+			waitFor(t, "ticks after resume", func() bool { return game.Ticks() > ticks })
+		})
+	}
+}
+
+func TestPauseNotRunning(t *testing.T) {
+	var room = newbee.NewRoom(1)
+	if err := room.Pause(); err != newbee.ErrRoomNotRunning {
+		t.Fatalf("got %v, want %v", err, newbee.ErrRoomNotRunning)
+	}
+	if err := room.Resume(); err != newbee.ErrRoomNotRunning {
+		t.Fatalf("got %v, want %v", err, newbee.ErrRoomNotRunning)
+	}
+}
+
+func TestPausePolicyReject(t *testing.T) {
+	var game = newbeetest.NewGame(1)
+	var room = newbeetest.RunRoom(t, game, newbee.WithSync(), newbee.WithPausePolicy(newbee.PausePolicyReject))
+	var sessions = newbeetest.JoinPlayers(t, room, 1)
+
+	room.Pause()
+	sessions[0].Inject(newPacket("a"))
+	room.Resume()
+	sessions[0].Inject(newPacket("b"))
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnPause", "OnResume", "OnMessage")
+
+	if got := packetData(game.Calls()[4].Message); got != "b" {
+		t.Fatalf("got %s, want b", got)
+	}
+}
+
+func TestPauseBufferCapacity(t *testing.T) {
+	var game = newbeetest.NewGame(1)
+	var room = newbeetest.RunRoom(t, game, newbee.WithAsync(), newbee.WithQueueCapacity(2, newbee.QueuePolicyDropOldest))
+	var sessions = newbeetest.JoinPlayers(t, room, 1)
+
+	room.Pause()
+	for _, data := range []string{"a", "b", "c", "d"} {
+		sessions[0].Inject(newPacket(data))
+	}
+	room.Enqueue("paused")
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnPause", "OnDequeue")
+
+	// 缓存的消息数量不会超过队列的容量
+	room.Resume()
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnPause", "OnDequeue", "OnResume", "OnMessage", "OnMessage")
+
+	for i, want := range []string{"c", "d"} {
+		if got := packetData(game.Calls()[5+i].Message); got != want {
+			t.Fatalf("message %d: got %s, want %s", i, got, want)
+		}
+	}
+	if n := room.GetDroppedCount(); n != 2 {
+		t.Fatalf("got %d dropped messages, want 2", n)
+	}
+}
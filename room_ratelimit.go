@@ -0,0 +1,113 @@
+package newbee
+
+import (
+	"github.com/smartwalle/net4go"
+	"sync"
+	"time"
+)
+
+type RateLimitPolicy int
+
+const (
+	RateLimitPolicyDrop       RateLimitPolicy = iota // 丢弃超出限制的消息
+	RateLimitPolicyNotify                            // 丢弃超出限制的消息，并交由 RateLimitHandler 的 OnRateLimited 方法处理
+	RateLimitPolicyDisconnect                        // 丢弃超出限制的消息，并断开玩家的连接
+)
+
+// tokenBucket 令牌桶，每秒生成 rate 个令牌，最多存储 burst 个令牌
+type tokenBucket struct {
+	last    time.Time
+	tokens  float64
+	limited uint64
+}
+
+func (b *tokenBucket) allow(rate float64, burst int, now time.Time) bool {
+	if b.last.IsZero() {
+		b.tokens = float64(burst)
+	} else {
+		b.tokens += now.Sub(b.last).Seconds() * rate
+		if b.tokens > float64(burst) {
+			b.tokens = float64(burst)
+		}
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		b.limited++
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+type rateLimiter struct {
+	buckets map[int64]*tokenBucket
+	rate    float64
+	burst   int
+	policy  RateLimitPolicy
+	mu      sync.Mutex
+}
+
+func newRateLimiter(rate float64, burst int, policy RateLimitPolicy) *rateLimiter {
+	var l = &rateLimiter{}
+	l.buckets = make(map[int64]*tokenBucket)
+	l.rate = rate
+	l.burst = burst
+	l.policy = policy
+	return l
+}
+
+func (l *rateLimiter) allow(playerId int64, now time.Time) bool {
+	l.mu.Lock()
+	var b = l.buckets[playerId]
+	if b == nil {
+		b = &tokenBucket{}
+		l.buckets[playerId] = b
+	}
+	var ok = b.allow(l.rate, l.burst, now)
+	l.mu.Unlock()
+	return ok
+}
+
+func (l *rateLimiter) limited(playerId int64) uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if b := l.buckets[playerId]; b != nil {
+		return b.limited
+	}
+	return 0
+}
+
+// reset 在玩家离开房间的时候重置玩家的令牌，保留被丢弃的消息数量，玩家重新加入房间之后可以突发 burst 条消息
+func (l *rateLimiter) reset(playerId int64) {
+	l.mu.Lock()
+	if b := l.buckets[playerId]; b != nil {
+		b.last = time.Time{}
+	}
+	l.mu.Unlock()
+}
+
+// allowMessage 检查玩家的消息是否超出限制，超出限制的时候根据 WithRateLimit 设置的策略进行处理
+func (r *room) allowMessage(sess net4go.Session, playerId int64, p net4go.Packet) bool {
+	if r.limiter == nil || r.limiter.allow(playerId, r.clock.Now()) {
+		return true
+	}
+
+	switch r.limiter.policy {
+	case RateLimitPolicyNotify:
+		var m = r.newMessage(playerId, mTypeRateLimited, p, nil)
+		if m != nil {
+			r.enqueueMessage(sess, m)
+		}
+	case RateLimitPolicyDisconnect:
+		sess.Close()
+	}
+	return false
+}
+
+func (r *room) GetRateLimitedCount(playerId int64) uint64 {
+	if r.limiter == nil {
+		return 0
+	}
+	return r.limiter.limited(playerId)
+}
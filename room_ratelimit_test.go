@@ -0,0 +1,84 @@
+package newbee_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/smartwalle/newbee"
+	"github.com/smartwalle/newbee/newbeetest"
+)
+
+func TestRateLimit(t *testing.T) {
+	var clock = newbee.NewFakeClock(time.Now())
+	var game = newbeetest.NewGame(1)
+	game.Interval = 0
+	var room = newbeetest.RunRoom(t, game, newbee.WithSync(), newbee.WithClock(clock), newbee.WithRateLimit(1, 2, newbee.RateLimitPolicyDrop))
+	var sessions = newbeetest.JoinPlayers(t, room, 1)
+
+	for _, data := range []string{"a", "b", "c"} {
+		sessions[0].Inject(newPacket(data))
+	}
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnMessage", "OnMessage")
+	if n := room.GetRateLimitedCount(1); n != 1 {
+		t.Fatalf("got %d rate limited messages, want 1", n)
+	}
+
+	// 每秒生成一个令牌
+	clock.Advance(time.Second)
+	sessions[0].Inject(newPacket("d"))
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnMessage", "OnMessage", "OnMessage")
+	if got := packetData(game.Calls()[4].Message); got != "d" {
+		t.Fatalf("got %s, want d", got)
+	}
+}
+
+func TestRateLimitNotify(t *testing.T) {
+	var clock = newbee.NewFakeClock(time.Now())
+	var game = newbeetest.NewGame(1)
+	game.Interval = 0
+	var room = newbeetest.RunRoom(t, game, newbee.WithAsync(), newbee.WithClock(clock), newbee.WithRateLimit(1, 1, newbee.RateLimitPolicyNotify))
+	var sessions = newbeetest.JoinPlayers(t, room, 1)
+
+	sessions[0].Inject(newPacket("a"))
+	sessions[0].Inject(newPacket("b"))
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnMessage", "OnRateLimited")
+	if call := game.Calls()[3]; call.PlayerId != 1 || packetData(call.Message) != "b" {
+		t.Fatalf("got %+v, want the dropped message from player 1", call)
+	}
+}
+
+func TestRateLimitDisconnect(t *testing.T) {
+	var clock = newbee.NewFakeClock(time.Now())
+	var game = newbeetest.NewGame(1)
+	game.Interval = 0
+	var room = newbeetest.RunRoom(t, game, newbee.WithAsync(), newbee.WithClock(clock), newbee.WithRateLimit(1, 1, newbee.RateLimitPolicyDisconnect))
+	var sessions = newbeetest.JoinPlayers(t, room, 1)
+
+	sessions[0].Inject(newPacket("a"))
+	sessions[0].Inject(newPacket("b"))
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnMessage", "OnLeaveRoom")
+	if reason := newbee.GetLeaveReason(game.Calls()[3].Error); reason != newbee.LeaveReasonDisconnect {
+		t.Fatalf("got reason %s, want %s", reason, newbee.LeaveReasonDisconnect)
+	}
+
+	// 玩家离开房间之后依然可以获取被丢弃的消息数量
+	if n := room.GetRateLimitedCount(1); n != 1 {
+		t.Fatalf("got %d rate limited messages, want 1", n)
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	var game = newbeetest.NewGame(1)
+	game.Interval = 0
+	var room = newbeetest.RunRoom(t, game, newbee.WithAsync(), newbee.WithRateLimit(0, 0, newbee.RateLimitPolicyDrop))
+	var sessions = newbeetest.JoinPlayers(t, room, 1)
+
+	// rate 和 burst 为 0 的时候不限制玩家发送消息的频率
+	for _, data := range []string{"a", "b", "c"} {
+		sessions[0].Inject(newPacket(data))
+	}
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnMessage", "OnMessage", "OnMessage")
+	if n := room.GetRateLimitedCount(1); n != 0 {
+		t.Fatalf("got %d rate limited messages, want 0", n)
+	}
+}
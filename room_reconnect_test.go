@@ -0,0 +1,95 @@
+package newbee_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/smartwalle/net4go"
+	"github.com/smartwalle/newbee"
+	"github.com/smartwalle/newbee/newbeetest"
+)
+
+func TestReconnect(t *testing.T) {
+	for name, mode := range newbeetest.Modes() {
+		if name == "lockstep" {
+			continue
+		}
+
+		t.Run(name, func(t *testing.T) {
+			var game = newbeetest.NewGame(1)
+			var room = newbeetest.RunRoom(t, game, mode, newbee.WithReconnectTimeout(time.Minute))
+			var sessions = newbeetest.JoinPlayers(t, room, 1)
+
+			var errBroken = errors.New("broken pipe")
+			sessions[0].CloseWithError(errBroken)
+			newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnPlayerDisconnected")
+
+			if got := game.Calls()[2].Error; got != errBroken {
+				t.Fatalf("got %v, want %v", got, errBroken)
+			}
+			if room.GetPlayer(1) == nil {
+				t.Fatal("disconnected player was removed from the room")
+			}
+
+			var sess = newbeetest.NewSession()
+			if err := room.ReconnectPlayer(1, sess); err != nil {
+				t.Fatal(err)
+			}
+			sess.Inject(newPacket("back"))
+			newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnPlayerDisconnected", "OnPlayerReconnected", "OnMessage")
+
+			if err := room.ReconnectPlayer(2, newbeetest.NewSession()); !errors.Is(err, newbee.ErrPlayerNotExist) {
+				t.Fatalf("got %v, want %v", err, newbee.ErrPlayerNotExist)
+			}
+		})
+	}
+}
+
+func TestReconnectTimeout(t *testing.T) {
+	var clock = newbee.NewFakeClock(time.Now())
+	var game = newbeetest.NewGame(1)
+	game.Interval = 0
+	var room = newbeetest.RunRoom(t, game, newbee.WithAsync(), newbee.WithClock(clock), newbee.WithReconnectTimeout(time.Second))
+	var sessions = newbeetest.JoinPlayers(t, room, 1)
+
+	var errBroken = errors.New("broken pipe")
+	sessions[0].CloseWithError(errBroken)
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnPlayerDisconnected")
+
+	clock.Advance(time.Second)
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnPlayerDisconnected", "OnLeaveRoom")
+
+	var err = game.Calls()[3].Error
+	if reason := newbee.GetLeaveReason(err); reason != newbee.LeaveReasonReconnectTimeout {
+		t.Fatalf("got reason %s, want %s", reason, newbee.LeaveReasonReconnectTimeout)
+	}
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("got %v, want it to wrap %v", err, errBroken)
+	}
+}
+
+// minimalPlayer 只实现了 newbee.Player 接口，不支持断线重连
+type minimalPlayer struct {
+	sess net4go.Session
+	id   int64
+}
+
+func (p *minimalPlayer) GetId() int64                         { return p.id }
+func (p *minimalPlayer) Session() net4go.Session              { return p.sess }
+func (p *minimalPlayer) Connected() bool                      { return !p.sess.Closed() }
+func (p *minimalPlayer) SendPacket(packet net4go.Packet)      { p.sess.WritePacket(packet) }
+func (p *minimalPlayer) AsyncSendPacket(packet net4go.Packet) { p.sess.AsyncWritePacket(packet) }
+func (p *minimalPlayer) Close() error                         { return p.sess.Close() }
+
+func TestReconnectNotSessionUpdater(t *testing.T) {
+	var game = newbeetest.NewGame(1)
+	var room = newbeetest.RunRoom(t, game, newbee.WithSync(), newbee.WithReconnectTimeout(time.Minute))
+
+	if err := room.AddPlayer(&minimalPlayer{id: 1, sess: newbeetest.NewSession()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := room.ReconnectPlayer(1, newbeetest.NewSession()); !errors.Is(err, newbee.ErrNotSessionUpdater) {
+		t.Fatalf("got %v, want %v", err, newbee.ErrNotSessionUpdater)
+	}
+}
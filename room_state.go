@@ -0,0 +1,71 @@
+package newbee
+
+import (
+	"fmt"
+)
+
+type GameOverPolicy int
+
+const (
+	GameOverPolicyClose   GameOverPolicy = iota // 游戏进入 GameStateOver 之后关闭房间
+	GameOverPolicyPending                       // 游戏进入 GameStateOver 之后房间继续运行，等待游戏回到 GameStatePending 开始下一局
+)
+
+func (s GameState) String() string {
+	switch s {
+	case GameStatePending:
+		return "pending"
+	case GameStateGaming:
+		return "gaming"
+	case GameStateOver:
+		return "over"
+	case GameStateStop:
+		return "stop"
+	}
+	return fmt.Sprintf("GameState(%d)", uint16(s))
+}
+
+// gameStateTransitions 合法的游戏状态变化，GameStateStop 之后不能再变化
+var gameStateTransitions = map[GameState][]GameState{
+	GameStatePending: {GameStateGaming, GameStateStop},
+	GameStateGaming:  {GameStateOver, GameStateStop},
+	GameStateOver:    {GameStatePending, GameStateStop},
+}
+
+// checkGameState 检查 Game 的状态是否发生变化，在 Room 调用 Game 的回调方法之后调用
+// 不合法的状态变化会交由 OnPanic 处理，但是不会关闭房间
+func (r *room) checkGameState(game Game) {
+	var to = game.GetState()
+
+	r.gameStateMu.Lock()
+	var from = r.gameState
+	if from == to {
+		r.gameStateMu.Unlock()
+		return
+	}
+	r.gameState = to
+	r.gameStateMu.Unlock()
+
+	if !validGameState(from, to) {
+		game.OnPanic(r, fmt.Errorf("%w from %s to %s", ErrBadGameState, from, to))
+		return
+	}
+
+	if h, ok := game.(StateChangeHandler); ok {
+		h.OnStateChange(from, to)
+	}
+
+	if to == GameStateStop || (to == GameStateOver && r.gameOverPolicy == GameOverPolicyClose) {
+		r.Close()
+	}
+}
+
+// validGameState 游戏状态从 from 变化为 to 是否合法
+func validGameState(from, to GameState) bool {
+	for _, s := range gameStateTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
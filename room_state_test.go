@@ -0,0 +1,122 @@
+package newbee_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/smartwalle/newbee"
+	"github.com/smartwalle/newbee/newbeetest"
+)
+
+// stateChanges 获取 OnStateChange 收到的状态变化
+func stateChanges(game *newbeetest.Game) [][2]newbee.GameState {
+	var changes [][2]newbee.GameState
+	for _, call := range game.Calls() {
+		if call.Name == "OnStateChange" {
+			changes = append(changes, call.Message.([2]newbee.GameState))
+		}
+	}
+	return changes
+}
+
+func TestGameOverPolicyClose(t *testing.T) {
+	var game = newbeetest.NewGame(1)
+	game.Interval = 0
+	var room = newbeetest.RunRoom(t, game, newbee.WithAsync())
+
+	game.SetState(newbee.GameStateGaming)
+	room.Enqueue("start")
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnDequeue", "OnStateChange")
+
+	game.SetState(newbee.GameStateOver)
+	room.Enqueue("over")
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnDequeue", "OnStateChange", "OnDequeue", "OnStateChange", "OnCloseRoom")
+
+	var want = [][2]newbee.GameState{{newbee.GameStatePending, newbee.GameStateGaming}, {newbee.GameStateGaming, newbee.GameStateOver}}
+	if got := stateChanges(game); len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if room.GetState() != newbee.RoomStateClose {
+		t.Fatalf("got state %d, want %d", room.GetState(), newbee.RoomStateClose)
+	}
+}
+
+func TestGameOverPolicyPending(t *testing.T) {
+	var game = newbeetest.NewGame(1)
+	game.Interval = 0
+	var room = newbeetest.RunRoom(t, game, newbee.WithSync(), newbee.WithGameOverPolicy(newbee.GameOverPolicyPending))
+
+	game.SetState(newbee.GameStateGaming)
+	room.Enqueue("start")
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnDequeue", "OnStateChange")
+
+	// 一局结束之后房间继续运行，可以回到 GameStatePending
+	game.SetState(newbee.GameStateOver)
+	room.Enqueue("over")
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnDequeue", "OnStateChange", "OnDequeue", "OnStateChange")
+	game.SetState(newbee.GameStatePending)
+	room.Enqueue("restart")
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnDequeue", "OnStateChange", "OnDequeue", "OnStateChange", "OnDequeue", "OnStateChange")
+
+	var want = [][2]newbee.GameState{{newbee.GameStateGaming, newbee.GameStateOver}, {newbee.GameStateOver, newbee.GameStatePending}}
+	if got := stateChanges(game)[1:]; len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if room.GetState() != newbee.RoomStateRunning {
+		t.Fatalf("got state %d, want %d", room.GetState(), newbee.RoomStateRunning)
+	}
+}
+
+func TestGameStateStop(t *testing.T) {
+	var game = newbeetest.NewGame(1)
+	game.Interval = 0
+	var room = newbeetest.RunRoom(t, game, newbee.WithSync(), newbee.WithGameOverPolicy(newbee.GameOverPolicyPending))
+
+	game.SetState(newbee.GameStateStop)
+	room.Enqueue("stop")
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnDequeue", "OnStateChange", "OnCloseRoom")
+}
+
+func TestGameStateSkipped(t *testing.T) {
+	var game = newbeetest.NewGame(1)
+	game.Interval = 0
+	var room = newbeetest.RunRoom(t, game, newbee.WithSync())
+
+	game.SetState(newbee.GameStateGaming)
+	room.Enqueue("start")
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnDequeue", "OnStateChange")
+
+	// GameStateGaming 不能直接回到 GameStatePending，不会补全 GameStateOver，也不会关闭房间
+	game.SetState(newbee.GameStatePending)
+	room.Enqueue("restart")
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnDequeue", "OnStateChange", "OnDequeue", "OnPanic")
+
+	if err := game.Calls()[4].Error; !errors.Is(err, newbee.ErrBadGameState) {
+		t.Fatalf("got %v, want %v", err, newbee.ErrBadGameState)
+	}
+	if room.GetState() != newbee.RoomStateRunning {
+		t.Fatalf("got state %d, want %d", room.GetState(), newbee.RoomStateRunning)
+	}
+}
+
+func TestGameStateBadTransition(t *testing.T) {
+	var game = newbeetest.NewGame(1)
+	game.Interval = 0
+	game.SetState(newbee.GameStateStop)
+	var room = newbeetest.RunRoom(t, game, newbee.WithSync())
+
+	// 离开 GameStateStop 的状态变化不合法，交由 OnPanic 处理，但是不会关闭房间
+	game.SetState(newbee.GameStatePending)
+	room.Enqueue("resume")
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnDequeue", "OnPanic")
+
+	if err := game.Calls()[2].Error; !errors.Is(err, newbee.ErrBadGameState) {
+		t.Fatalf("got %v, want %v", err, newbee.ErrBadGameState)
+	}
+	if room.GetState() != newbee.RoomStateRunning {
+		t.Fatalf("got state %d, want %d", room.GetState(), newbee.RoomStateRunning)
+	}
+
+	room.Enqueue("next")
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnDequeue", "OnPanic", "OnDequeue")
+}
@@ -7,7 +7,8 @@ import (
 
 type syncRoom struct {
 	*room
-	timer *time.Timer
+	timer ClockTimer
+	gen   uint64 // 定时器的版本，修改刷新时间间隔之后，旧的定时器放入队列的 tick 消息会被忽略
 }
 
 func newSyncRoom(room *room) roomMode {
@@ -16,6 +17,10 @@ func newSyncRoom(room *room) roomMode {
 	return r
 }
 
+func (r *syncRoom) Check(game Game) error {
+	return nil
+}
+
 func (r *syncRoom) Run(game Game) (err error) {
 	//if game == nil {
 	//	return ErrNilGame
@@ -37,10 +42,8 @@ func (r *syncRoom) Run(game Game) (err error) {
 	//
 	//game.OnRunInRoom(r)
 
-	var d = game.TickInterval()
-	if d > 0 {
-		r.tick(d)
-	}
+	r.ticker = newTickScheduler(r.clock, r.tickPolicy, TickPolicyFixedDelay)
+	r.resetTick()
 
 	var mList []*message
 
@@ -65,6 +68,7 @@ RunLoop:
 	for {
 		mList = mList[0:0]
 		var ok = r.queue.Dequeue(&mList)
+		r.observeDequeue(mList)
 
 		for _, m := range mList {
 			//if m == nil {
@@ -72,17 +76,15 @@ RunLoop:
 			//}
 
 			switch m.Type {
-			case mTypeDefault:
-				r.onMessage(game, m.PlayerId, m.Data)
-			case mTypeCustom:
-				r.onDequeue(game, m.Data)
-			case mTypePlayerIn:
-				m.rError <- r.onJoinRoom(game, m.Player)
-			case mTypePlayerOut:
-				r.onLeaveRoom(game, m.PlayerId, m.Error)
 			case mTypeTick:
-				game.OnTick()
-				r.tick(d)
+				if gen, _ := m.Data.(uint64); gen == r.gen {
+					r.ticker.begin()
+					r.tick(r.onTick(game))
+				}
+			case mTypeTickInterval:
+				r.resetTick()
+			default:
+				r.dispatch(game, m)
 			}
 			r.releaseMessage(m)
 		}
@@ -95,12 +97,36 @@ RunLoop:
 }
 
 func (r *syncRoom) tick(d time.Duration) {
-	r.timer = time.AfterFunc(d, func() {
-		var m = r.newMessage(0, mTypeTick, nil, nil)
-		r.queue.Enqueue(m)
+	var gen = r.gen
+	r.timer = r.clock.AfterFunc(d, func() {
+		var m = r.newMessage(0, mTypeTick, gen, nil)
+		if m != nil {
+			r.queue.Enqueue(m)
+		}
 	})
 }
 
+// resetTick 按照当前的刷新时间间隔重新设置定时器
+func (r *syncRoom) resetTick() {
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+	r.gen++
+
+	var d = r.ticker.reset(r.currentTickInterval())
+	if !r.ticker.paused {
+		r.tick(d)
+	}
+}
+
+func (r *syncRoom) OnTickInterval() {
+	var m = r.newMessage(0, mTypeTickInterval, nil, nil)
+	if m != nil {
+		r.queue.Enqueue(m)
+	}
+}
+
 func (r *syncRoom) OnClose() error {
 	return nil
 }
@@ -0,0 +1,129 @@
+package newbee_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/smartwalle/net4go"
+	"github.com/smartwalle/newbee"
+	"github.com/smartwalle/newbee/newbeetest"
+)
+
+// plainGame 只实现了 newbee.Game 接口，隐藏 newbeetest.Game 实现的其它可选接口
+type plainGame struct {
+	newbee.Game
+}
+
+func newPacket(data string) *net4go.DefaultPacket {
+	return net4go.NewDefaultPacket(1, []byte(data))
+}
+
+// packetData 获取 newPacket 创建的消息的内容
+func packetData(message interface{}) string {
+	if p, ok := message.(*net4go.DefaultPacket); ok {
+		return string(p.GetData())
+	}
+	return ""
+}
+
+// waitFor 等待 fn 返回 true，超时的时候测试失败
+func waitFor(t *testing.T, what string, fn func() bool) {
+	t.Helper()
+
+	var deadline = time.Now().Add(time.Second)
+	for !fn() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRunContext(t *testing.T) {
+	var game = newbeetest.NewGame(1)
+	var room = newbee.NewRoom(1)
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	var rErr = make(chan error, 1)
+	go func() {
+		rErr <- room.RunContext(ctx, game)
+	}()
+	waitFor(t, "the room to run", func() bool { return room.GetState() == newbee.RoomStateRunning })
+
+	cancel()
+	select {
+	case err := <-rErr:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("RunContext did not return after ctx was canceled")
+	}
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnCloseRoom")
+}
+
+func TestShutdown(t *testing.T) {
+	for name, mode := range newbeetest.Modes() {
+		t.Run(name, func(t *testing.T) {
+			var game = newbeetest.NewGame(1)
+			var room = newbeetest.RunRoom(t, game, mode)
+			newbeetest.JoinPlayers(t, room, 1)
+
+			var ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			if err := room.Shutdown(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			// Shutdown 返回的时候已经处理完 OnLeaveRoom 和 OnCloseRoom
+			var names = game.Names()
+			if len(names) != 4 || names[2] != "OnLeaveRoom" || names[3] != "OnCloseRoom" {
+				t.Fatalf("got %v, want OnLeaveRoom and OnCloseRoom", names)
+			}
+			if reason := newbee.GetLeaveReason(game.Calls()[2].Error); reason != newbee.LeaveReasonShutdown {
+				t.Fatalf("got reason %s, want %s", reason, newbee.LeaveReasonShutdown)
+			}
+			if err := room.Run(game); !errors.Is(err, newbee.ErrRoomClosed) {
+				t.Fatalf("got %v, want %v", err, newbee.ErrRoomClosed)
+			}
+		})
+	}
+}
+
+// joinGame 实现了 JoinRequestHandler，拒绝 id 为负数的玩家
+type joinGame struct {
+	*newbeetest.Game
+}
+
+var errNegativeId = errors.New("negative player id")
+
+func (g joinGame) OnJoinRequest(player newbee.Player) error {
+	if player.GetId() < 0 {
+		return errNegativeId
+	}
+	return nil
+}
+
+func TestMaxPlayers(t *testing.T) {
+	for name, mode := range newbeetest.Modes() {
+		t.Run(name, func(t *testing.T) {
+			var game = joinGame{newbeetest.NewGame(1)}
+			var room = newbeetest.RunRoom(t, game, mode, newbee.WithMaxPlayers(2))
+
+			if err := room.AddPlayer(newbee.NewPlayer(-1, newbeetest.NewSession())); err != errNegativeId {
+				t.Fatalf("got %v, want %v", err, errNegativeId)
+			}
+
+			newbeetest.JoinPlayers(t, room, 2)
+			if err := room.AddPlayer(newbee.NewPlayer(3, newbeetest.NewSession())); !errors.Is(err, newbee.ErrRoomFull) {
+				t.Fatalf("got %v, want %v", err, newbee.ErrRoomFull)
+			}
+			if err := room.AddPlayer(newbee.NewPlayer(1, newbeetest.NewSession())); err == nil {
+				t.Fatal("added a player twice")
+			}
+			newbeetest.ExpectCalls(t, game.Game, "OnRunInRoom", "OnJoinRoom", "OnJoinRoom")
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package newbee
+
+import (
+	"time"
+)
+
+type TickPolicy int
+
+const (
+	TickPolicyDefault    TickPolicy = iota // 使用运行模式默认的策略，async 模式为 TickPolicySkip，sync 和 frame 模式为 TickPolicyFixedDelay
+	TickPolicyFixedDelay                   // 上一次 tick 执行完成之后，间隔 TickInterval 再执行下一次 tick，执行时间较长的时候实际频率会变慢
+	TickPolicyFixedRate                    // 按照固定的频率执行 tick，落后的时候会立即执行下一次 tick 追赶进度
+	TickPolicySkip                         // 按照固定的频率执行 tick，落后的时候跳过已经错过的 tick
+)
+
+// TickOverrun tick 超时信息，一次 tick 从预定的时间开始到执行完成超过了 TickInterval 即为超时
+type TickOverrun struct {
+	Interval time.Duration // 设置的刷新时间间隔
+	Delta    time.Duration // 本次 tick 和上一次 tick 实际的时间间隔
+	Late     time.Duration // 超出 Interval 的时间
+	Skipped  int           // 跳过的 tick 数量，只有 TickPolicySkip 策略会跳过 tick
+}
+
+// TickInfo 本次 tick 的信息，时间都来自 Room 的 Clock，可以通过 WithClock 设置
+type TickInfo struct {
+	Frame uint64        // tick 序号，从 1 开始，WithFrame 和 WithLockstep 模式下和帧 id 相同
+	Delta time.Duration // 本次 tick 和上一次 tick 实际的时间间隔，第一次 tick 为 TickInterval
+	Now   time.Time     // 本次 tick 开始的时间
+}
+
+// tickScheduler 根据 TickPolicy 计算下一次 tick 的时间
+type tickScheduler struct {
+	clock     Clock
+	policy    TickPolicy
+	interval  time.Duration // 最近一次设置的大于 0 的刷新时间间隔
+	paused    bool          // 刷新时间间隔被设置为小于等于 0 的值，暂停 tick
+	scheduled time.Time     // 下一次 tick 预定的执行时间
+	start     time.Time     // 本次 tick 实际的开始时间
+	delta     time.Duration
+	frame     uint64
+}
+
+func newTickScheduler(clock Clock, policy TickPolicy, def TickPolicy) *tickScheduler {
+	if policy == TickPolicyDefault {
+		policy = def
+	}
+	var s = &tickScheduler{}
+	s.clock = clock
+	s.policy = policy
+	return s
+}
+
+// reset 重新设置刷新时间间隔，返回距离下一次 tick 的时间，interval 小于等于 0 的时候暂停 tick 并返回 0
+// 重新设置之后的第一次 tick 的 Delta 为 interval
+func (s *tickScheduler) reset(interval time.Duration) time.Duration {
+	if interval <= 0 {
+		s.paused = true
+		return 0
+	}
+	s.paused = false
+	s.interval = interval
+	s.scheduled = s.clock.Now().Add(interval)
+	s.start = time.Time{}
+	return interval
+}
+
+// begin 在 tick 开始的时候调用
+func (s *tickScheduler) begin() {
+	var now = s.clock.Now()
+	if s.start.IsZero() {
+		s.delta = s.interval
+	} else {
+		s.delta = now.Sub(s.start)
+	}
+	s.start = now
+	s.frame++
+}
+
+func (s *tickScheduler) info() TickInfo {
+	return TickInfo{Frame: s.frame, Delta: s.delta, Now: s.start}
+}
+
+// next 在 tick 执行完成之后调用，返回距离下一次 tick 的时间，本次 tick 超时的时候 overrun 不为 nil
+func (s *tickScheduler) next() (delay time.Duration, overrun *TickOverrun) {
+	var now = s.clock.Now()
+
+	var deadline time.Time
+	if s.policy == TickPolicyFixedDelay {
+		deadline = s.start.Add(s.interval)
+	} else {
+		deadline = s.scheduled.Add(s.interval)
+	}
+
+	if now.After(deadline) {
+		overrun = &TickOverrun{Interval: s.interval, Delta: s.delta, Late: now.Sub(deadline)}
+	}
+
+	switch s.policy {
+	case TickPolicyFixedDelay:
+		s.scheduled = now.Add(s.interval)
+	case TickPolicyFixedRate:
+		s.scheduled = s.scheduled.Add(s.interval)
+	case TickPolicySkip:
+		s.scheduled = s.scheduled.Add(s.interval)
+		if now.After(s.scheduled) {
+			var skipped = int(now.Sub(s.scheduled)/s.interval) + 1
+			s.scheduled = s.scheduled.Add(time.Duration(skipped) * s.interval)
+			overrun.Skipped = skipped
+		}
+	}
+
+	delay = s.scheduled.Sub(now)
+	if delay < 0 {
+		delay = 0
+	}
+	return delay, overrun
+}
+
+func (r *room) SetTickInterval(d time.Duration) error {
+	r.mu.Lock()
+	if !r.active() {
+		r.mu.Unlock()
+		return ErrRoomNotRunning
+	}
+	r.tickInterval = d
+	var mode = r.mode
+	r.mu.Unlock()
+
+	mode.OnTickInterval()
+	return nil
+}
+
+func (r *room) GetTickInterval() time.Duration {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.tickInterval
+}
+
+// onTick 调用 Game 的 OnTick（或者 TickerWithInfo 的 OnTickInfo）方法，返回距离下一次 tick 的时间，调用之前需要先调用 r.ticker.begin
+func (r *room) onTick(game Game) time.Duration {
+	var s = r.ticker
+	var now = r.clock.Now()
+
+	var info = s.info()
+	r.record(RecordTypeTick, 0, info, nil)
+	tick(game, info)
+
+	var delay, overrun = s.next()
+
+	r.checkGameState(game)
+
+	if r.metrics != nil {
+		r.metrics.ObserveTick(r.id, r.clock.Now().Sub(now), s.delta-s.interval)
+	}
+
+	if overrun != nil {
+		if r.metrics != nil {
+			r.metrics.IncTickOverrun(r.id, overrun.Skipped)
+		}
+		if handler, ok := game.(TickOverrunHandler); ok {
+			handler.OnTickOverrun(*overrun)
+		}
+	}
+	return delay
+}
+
+func tick(game Game, info TickInfo) {
+	if ticker, ok := game.(TickerWithInfo); ok {
+		ticker.OnTickInfo(info)
+		return
+	}
+	game.OnTick()
+}
@@ -0,0 +1,165 @@
+package newbee_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/smartwalle/newbee"
+	"github.com/smartwalle/newbee/newbeetest"
+)
+
+// tickGame 记录每一次 tick 的信息，第一次 tick 会等待 proceed 之后将时钟推进 work，模拟执行时间较长的 tick
+type tickGame struct {
+	*newbeetest.Game
+	clock    *newbee.FakeClock
+	proceed  chan struct{}
+	work     time.Duration
+	mu       sync.Mutex
+	infos    []newbee.TickInfo
+	overruns []newbee.TickOverrun
+}
+
+func newTickGame(clock *newbee.FakeClock, work time.Duration) *tickGame {
+	var g = &tickGame{}
+	g.Game = newbeetest.NewGame(1)
+	g.clock = clock
+	g.proceed = make(chan struct{}, 1)
+	g.work = work
+	return g
+}
+
+func (g *tickGame) OnTickInfo(info newbee.TickInfo) {
+	g.mu.Lock()
+	g.infos = append(g.infos, info)
+	g.mu.Unlock()
+
+	if info.Frame == 1 && g.work > 0 {
+		<-g.proceed
+		g.clock.Advance(g.work)
+	}
+	g.Game.OnTick()
+}
+
+func (g *tickGame) OnTickOverrun(overrun newbee.TickOverrun) {
+	g.mu.Lock()
+	g.overruns = append(g.overruns, overrun)
+	g.mu.Unlock()
+}
+
+func (g *tickGame) result() ([]newbee.TickInfo, []newbee.TickOverrun) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]newbee.TickInfo(nil), g.infos...), append([]newbee.TickOverrun(nil), g.overruns...)
+}
+
+func TestTickOverrun(t *testing.T) {
+	var tests = []struct {
+		name    string
+		policy  newbee.TickPolicy
+		advance time.Duration // 第一次 tick 完成之后，距离第二次 tick 的时间
+		overrun newbee.TickOverrun
+	}{
+		{
+			name:    "fixed delay",
+			policy:  newbee.TickPolicyFixedDelay,
+			advance: time.Millisecond * 10,
+			overrun: newbee.TickOverrun{Interval: time.Millisecond * 10, Delta: time.Millisecond * 10, Late: time.Millisecond * 15},
+		},
+		{
+			name:    "skip",
+			policy:  newbee.TickPolicySkip,
+			advance: time.Millisecond * 5,
+			overrun: newbee.TickOverrun{Interval: time.Millisecond * 10, Delta: time.Millisecond * 10, Late: time.Millisecond * 15, Skipped: 2},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clock = newbee.NewFakeClock(time.Unix(1000, 0))
+			var game = newTickGame(clock, time.Millisecond*25)
+			newbeetest.RunRoom(t, game, newbee.WithSync(), newbee.WithClock(clock), newbee.WithTickPolicy(test.policy))
+
+			clock.BlockUntil(1)
+			clock.Advance(game.Interval)
+			game.proceed <- struct{}{}
+			waitFor(t, "the first tick", func() bool { return game.Ticks() == 1 })
+
+			clock.BlockUntil(1)
+			clock.Advance(test.advance)
+			waitFor(t, "the second tick", func() bool { return game.Ticks() == 2 })
+
+			var infos, overruns = game.result()
+			if len(overruns) != 1 || overruns[0] != test.overrun {
+				t.Fatalf("got overruns %+v, want %+v", overruns, test.overrun)
+			}
+			if want := time.Millisecond*25 + test.advance; infos[1].Delta != want {
+				t.Fatalf("got delta %v, want %v", infos[1].Delta, want)
+			}
+		})
+	}
+}
+
+func TestTickInfo(t *testing.T) {
+	for name, mode := range newbeetest.Modes() {
+		t.Run(name, func(t *testing.T) {
+			var start = time.Unix(1000, 0)
+			var clock = newbee.NewFakeClock(start)
+			var game = newTickGame(clock, 0)
+			newbeetest.RunRoom(t, game, mode, newbee.WithClock(clock))
+
+			for i := 1; i <= 3; i++ {
+				clock.BlockUntil(1)
+				clock.Advance(game.Interval)
+				waitFor(t, "the next tick", func() bool { return game.Ticks() >= i })
+			}
+
+			var infos, overruns = game.result()
+			for i, info := range infos {
+				var want = newbee.TickInfo{Frame: uint64(i + 1), Delta: game.Interval, Now: start.Add(game.Interval * time.Duration(i+1))}
+				if info != want {
+					t.Fatalf("tick %d: got %+v, want %+v", i+1, info, want)
+				}
+			}
+			if len(overruns) != 0 {
+				t.Fatalf("got overruns %+v, want none", overruns)
+			}
+		})
+	}
+}
+
+func TestSetTickInterval(t *testing.T) {
+	if err := newbee.NewRoom(1).SetTickInterval(time.Millisecond); err != newbee.ErrRoomNotRunning {
+		t.Fatalf("got %v, want %v", err, newbee.ErrRoomNotRunning)
+	}
+
+	for name, mode := range newbeetest.Modes() {
+		t.Run(name, func(t *testing.T) {
+			var game = newbeetest.NewGame(1)
+			var room = newbeetest.RunRoom(t, game, mode)
+			newbeetest.JoinPlayers(t, room, 1)
+			waitFor(t, "ticks", func() bool { return game.Ticks() > 0 })
+
+			if err := room.SetTickInterval(0); err != nil {
+				t.Fatal(err)
+			}
+			if d := room.GetTickInterval(); d != 0 {
+				t.Fatalf("got interval %v, want 0", d)
+			}
+
+			// 暂停 tick 之后仍然会处理队列中的消息
+			room.Enqueue("paused")
+			newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnJoinRoom", "OnDequeue")
+			var ticks = game.Ticks()
+			time.Sleep(game.Interval * 3)
+			if game.Ticks() != ticks {
+				t.Fatalf("got %d ticks after the interval was set to 0, want %d", game.Ticks(), ticks)
+			}
+
+			if err := room.SetTickInterval(time.Millisecond * 5); err != nil {
+				t.Fatal(err)
+			}
+			waitFor(t, "ticks after the interval was restored", func() bool { return game.Ticks() > ticks })
+		})
+	}
+}
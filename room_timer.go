@@ -0,0 +1,137 @@
+package newbee
+
+import (
+	"sync"
+	"time"
+)
+
+// Timer 由 Room 的 AfterFunc 和 Every 方法创建的定时器
+type Timer interface {
+	// Stop 停止定时器，如果定时器已经停止或者已经触发（AfterFunc），则返回 false
+	Stop() bool
+}
+
+type roomTimer struct {
+	room     *room
+	fn       func()
+	timer    ClockTimer
+	interval time.Duration
+	seq      uint64 // 定时器的序号，按照创建的顺序从 1 开始递增，用于回放
+	mu       sync.Mutex
+	stopped  bool
+}
+
+func (t *roomTimer) schedule(d time.Duration) {
+	t.timer = t.room.clock.AfterFunc(d, func() {
+		var m = t.room.newMessage(0, mTypeTimer, t, nil)
+		if m != nil {
+			t.room.queue.Enqueue(m)
+		}
+	})
+}
+
+func (t *roomTimer) Stop() bool {
+	t.mu.Lock()
+	if t.stopped {
+		t.mu.Unlock()
+		return false
+	}
+	t.stopped = true
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	t.mu.Unlock()
+
+	t.room.removeTimer(t)
+	return true
+}
+
+func (t *roomTimer) Stopped() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stopped
+}
+
+// AfterFunc 在 d 之后执行 fn，fn 在 Room 的消息处理协程中执行
+func (r *room) AfterFunc(d time.Duration, fn func()) Timer {
+	return r.addTimer(d, 0, fn)
+}
+
+// Every 每隔 d 执行一次 fn，fn 在 Room 的消息处理协程中执行，下一次计时从 fn 执行完成之后开始
+func (r *room) Every(d time.Duration, fn func()) Timer {
+	return r.addTimer(d, d, fn)
+}
+
+func (r *room) addTimer(d, interval time.Duration, fn func()) Timer {
+	var t = &roomTimer{}
+	t.room = r
+	t.fn = fn
+	t.interval = interval
+
+	r.mu.Lock()
+	if r.state == RoomStateClose || fn == nil {
+		r.mu.Unlock()
+		t.stopped = true
+		return t
+	}
+	r.timerSeq++
+	t.seq = r.timerSeq
+	r.timers[t] = struct{}{}
+
+	// 持有 r.mu 的时候启动计时器，避免 Room 关闭的时候遗漏本定时器
+	t.mu.Lock()
+	t.schedule(d)
+	t.mu.Unlock()
+	r.mu.Unlock()
+	return t
+}
+
+func (r *room) removeTimer(t *roomTimer) {
+	r.mu.Lock()
+	delete(r.timers, t)
+	r.mu.Unlock()
+}
+
+// findTimer 根据序号查找还没有停止的定时器
+func (r *room) findTimer(seq uint64) *roomTimer {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for t := range r.timers {
+		if t.seq == seq {
+			return t
+		}
+	}
+	return nil
+}
+
+// stopTimers 停止所有的定时器
+func (r *room) stopTimers() {
+	r.mu.Lock()
+	var timers = r.timers
+	r.timers = make(map[*roomTimer]struct{})
+	r.mu.Unlock()
+
+	for t := range timers {
+		t.Stop()
+	}
+}
+
+func (r *room) onTimer(t *roomTimer) {
+	if t.Stopped() {
+		return
+	}
+
+	if t.interval <= 0 {
+		t.Stop()
+		t.fn()
+		return
+	}
+
+	t.fn()
+
+	t.mu.Lock()
+	if !t.stopped {
+		t.schedule(t.interval)
+	}
+	t.mu.Unlock()
+}
@@ -0,0 +1,66 @@
+package newbee_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/smartwalle/newbee"
+	"github.com/smartwalle/newbee/newbeetest"
+)
+
+func TestRoomTimer(t *testing.T) {
+	var clock = newbee.NewFakeClock(time.Now())
+	var game = newbeetest.NewGame(1)
+	game.Interval = 0
+	var room = newbeetest.RunRoom(t, game, newbee.WithAsync(), newbee.WithClock(clock))
+
+	// fn 在 Room 的消息处理协程中执行，可以直接调用 Game 的方法
+	var after = room.AfterFunc(time.Second, func() { game.OnDequeue("after") })
+	var every = room.Every(time.Second*2, func() { game.OnDequeue("every") })
+
+	clock.Advance(time.Second)
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnDequeue")
+	if after.Stop() {
+		t.Fatal("Stop returned true for a fired timer")
+	}
+
+	for i := 0; i < 2; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Second * 2)
+	}
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnDequeue", "OnDequeue", "OnDequeue")
+	for _, call := range game.Calls()[2:] {
+		if call.Message != "every" {
+			t.Fatalf("got %v, want every", call.Message)
+		}
+	}
+
+	clock.BlockUntil(1)
+	if !every.Stop() {
+		t.Fatal("Stop returned false for an active timer")
+	}
+	clock.Advance(time.Second * 2)
+	room.Enqueue("done")
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnDequeue", "OnDequeue", "OnDequeue", "OnDequeue")
+}
+
+func TestRoomTimerClose(t *testing.T) {
+	var clock = newbee.NewFakeClock(time.Now())
+	var game = newbeetest.NewGame(1)
+	game.Interval = 0
+	var room = newbeetest.RunRoom(t, game, newbee.WithSync(), newbee.WithClock(clock))
+
+	var timer = room.Every(time.Second, func() { game.OnDequeue("every") })
+	room.Close()
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnCloseRoom")
+
+	// 房间关闭的时候会停止所有的定时器
+	clock.Advance(time.Second)
+	if timer.Stop() {
+		t.Fatal("Stop returned true after the room was closed")
+	}
+	if timer = room.AfterFunc(time.Second, func() {}); timer.Stop() {
+		t.Fatal("AfterFunc created an active timer in a closed room")
+	}
+	newbeetest.ExpectCalls(t, game, "OnRunInRoom", "OnCloseRoom")
+}
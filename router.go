@@ -0,0 +1,80 @@
+package newbee
+
+// RouteHandler 消息处理函数
+type RouteHandler func(player Player, message interface{})
+
+// Router 按照消息类型分发玩家消息，Game 可以内嵌 *Router，Room 调用 Game 的 OnMessage 方法时会由 Router 完成分发
+//
+//	type Game struct {
+//		*newbee.Router[protocol.PacketType]
+//	}
+//
+//	var router = newbee.NewRouter(func(message interface{}) (protocol.PacketType, bool) {
+//		var p, ok = message.(*protocol.Packet)
+//		if !ok {
+//			return 0, false
+//		}
+//		return p.Type, true
+//	})
+//	newbee.Handle(router, protocol.Heartbeat, func(player newbee.Player, p *protocol.Packet) {})
+type Router[K comparable] struct {
+	key      func(message interface{}) (K, bool)
+	handlers map[K]RouteHandler
+	fallback RouteHandler
+	unknown  RouteHandler
+}
+
+// NewRouter 创建消息路由，key 用于从消息中获取消息类型，无法识别的消息返回 false
+func NewRouter[K comparable](key func(message interface{}) (K, bool)) *Router[K] {
+	var r = &Router[K]{}
+	r.key = key
+	r.handlers = make(map[K]RouteHandler)
+	return r
+}
+
+// Handle 注册消息处理函数，handler 接收的消息为具体的类型 T
+// 如果消息不能转换为类型 T，消息将交由 HandleUnknown 注册的处理函数处理
+func Handle[K comparable, T any](r *Router[K], key K, handler func(player Player, message T)) {
+	r.handlers[key] = func(player Player, message interface{}) {
+		var m, ok = message.(T)
+		if !ok {
+			r.onUnknown(player, message)
+			return
+		}
+		handler(player, m)
+	}
+}
+
+// HandleDefault 注册默认的消息处理函数，没有注册处理函数的消息类型将交由 handler 处理
+func (r *Router[K]) HandleDefault(handler RouteHandler) {
+	r.fallback = handler
+}
+
+// HandleUnknown 注册无法识别的消息的处理函数，无法获取消息类型或者消息类型和处理函数不匹配的消息将交由 handler 处理
+func (r *Router[K]) HandleUnknown(handler RouteHandler) {
+	r.unknown = handler
+}
+
+// OnMessage 分发消息，没有对应处理函数的消息将被丢弃
+func (r *Router[K]) OnMessage(player Player, message interface{}) {
+	var key, ok = r.key(message)
+	if !ok {
+		r.onUnknown(player, message)
+		return
+	}
+
+	if handler := r.handlers[key]; handler != nil {
+		handler(player, message)
+		return
+	}
+
+	if r.fallback != nil {
+		r.fallback(player, message)
+	}
+}
+
+func (r *Router[K]) onUnknown(player Player, message interface{}) {
+	if r.unknown != nil {
+		r.unknown(player, message)
+	}
+}
@@ -0,0 +1,64 @@
+package newbee_test
+
+import (
+	"testing"
+
+	"github.com/smartwalle/net4go"
+	"github.com/smartwalle/newbee"
+	"github.com/smartwalle/newbee/newbeetest"
+)
+
+// routerGame 通过内嵌的 Router 分发玩家消息
+type routerGame struct {
+	*newbeetest.Game
+	*newbee.Router[uint16]
+}
+
+func (g routerGame) OnMessage(player newbee.Player, message interface{}) {
+	g.Router.OnMessage(player, message)
+}
+
+func TestRouter(t *testing.T) {
+	var log = &eventLog{}
+	var router = newbee.NewRouter(func(message interface{}) (uint16, bool) {
+		switch p := message.(type) {
+		case *net4go.DefaultPacket:
+			return p.GetType(), true
+		case uint16:
+			return p, true
+		}
+		return 0, false
+	})
+	newbee.Handle(router, 1, func(player newbee.Player, p *net4go.DefaultPacket) {
+		log.add("packet:" + string(p.GetData()))
+	})
+	router.HandleDefault(func(player newbee.Player, message interface{}) {
+		log.add("default")
+	})
+	router.HandleUnknown(func(player newbee.Player, message interface{}) {
+		log.add("unknown")
+	})
+
+	var game = routerGame{Game: newbeetest.NewGame(1), Router: router}
+	var room = newbeetest.RunRoom(t, game, newbee.WithSync())
+	var sessions = newbeetest.JoinPlayers(t, room, 1)
+
+	sessions[0].Inject(newPacket("hello"))
+	sessions[0].Inject(net4go.NewDefaultPacket(2, nil))
+	waitFor(t, "routed messages", func() bool { return len(log.get()) == 2 })
+
+	// 类型为 1 但是不能转换为 *net4go.DefaultPacket 的消息交由 HandleUnknown 处理
+	router.OnMessage(nil, uint16(1))
+	router.OnMessage(nil, "text")
+
+	var got = log.get()
+	var want = []string{"packet:hello", "default", "unknown", "unknown"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}